@@ -25,7 +25,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -38,7 +37,6 @@ import (
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // addCmd represents the add command
@@ -48,81 +46,114 @@ var addCmd = &cobra.Command{
 	Long: `Scholar: a CLI Reference Manager
 
 Add a new entry to a library.
+
+The entry is validated after it is saved. If it has invalid fields, the
+problems are printed and scholar exits with status 7.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var entry *scholar.Entry
 		doi := doiFlag
 
 		input := strings.Join(args, " ")
 		file, err := homedir.Expand(input)
 		if err != nil {
-			panic(err)
+			return err
 		}
+		var files []scholar.Attachment
 		if _, err := os.Stat(file); os.IsNotExist(err) {
 			file = ""
 		} else {
 			input = ""
+			files = append(files, scholar.Attachment{Path: file})
 		}
-		if attachFlag != "" {
-			file, err = homedir.Expand(attachFlag)
-			if err != nil {
-				panic(err)
-			}
-			if _, err := os.Stat(file); os.IsNotExist(err) {
-				panic(fmt.Sprint("file '", file, "' not found"))
-			}
+		as, err := attachments(attachFlag)
+		if err != nil {
+			return err
+		}
+		files = append(files, as...)
+		if file == "" && len(files) > 0 {
+			file = files[0].Path
 		}
 
 		if doi == "" {
 			if input == "" {
 				if file != "" {
-					if doi = doiFromPDF(file); doi == "" {
-						if askYesNo("Would you like to search the web for metadata?") {
-							doi = query(requestSearch())
+					if doi, err = doiFromPDF(file); err != nil {
+						return err
+					}
+					if doi == "" {
+						ok, err := askYesNo("Would you like to search the web for metadata?")
+						if err != nil {
+							return err
+						}
+						if ok {
+							search, err := requestSearch()
+							if err != nil {
+								return err
+							}
+							if doi, err = query(search); err != nil {
+								return err
+							}
 						}
 					}
 
 				}
-			} else {
-				doi = query(input)
+			} else if doi, err = query(input); err != nil {
+				return err
 			}
 		}
 		if doi == "" {
-			entry = manual()
+			if entry, err = manual(); err != nil {
+				return err
+			}
 		} else {
 			info.println("Extracting metadata from:", doi)
 			if entry, err = fetchDOI(doi); err != nil {
 				info.println("Could not get metadata from:", doi)
-				entry = manual()
+				if entry, err = manual(); err != nil {
+					return err
+				}
 			}
 		}
 
-		commit(entry)
-		if file != "" {
-			info.println("  .. attaching:", file)
-			attach(entry, file)
+		if err := checkDuplicates(entry); err != nil {
+			return err
+		}
+		if err := commit(entry); err != nil {
+			return err
+		}
+		if err := attach(entry, files...); err != nil {
+			return err
 		}
 		if isInteractive() {
-			edit(entry)
+			if err := edit(entry); err != nil {
+				return err
+			}
 		}
+		valid := checkEntry(entry)
 
 		info.println()
 		info.println(entry.Bib())
+		if !valid {
+			return errorf(exitInvalid, "%s was saved with invalid fields", entry.GetKey())
+		}
+		return nil
 	},
 }
 
-var doiFlag, attachFlag string
+var doiFlag string
+var attachFlag []string
 
 func init() {
 	rootCmd.AddCommand(addCmd)
 
 	addCmd.Flags().StringVarP(&doiFlag, "doi", "d", "", "Specify the DOI to retrieve metadata")
-	addCmd.Flags().StringVarP(&attachFlag, "attach", "a", "", "attach a file to the entry")
+	addCmd.Flags().StringArrayVarP(&attachFlag, "attach", "a", nil, "attach a file to the entry as [ROLE=]PATH (can be repeated)")
 }
 
-func askYesNo(question string) bool {
+func askYesNo(question string) (bool, error) {
 	if !isInteractive() {
-		return false
+		return false, nil
 	}
 	prompt := promptui.Prompt{
 		Label:     question,
@@ -131,70 +162,52 @@ func askYesNo(question string) bool {
 
 	res, _ := prompt.Run()
 	if res == "" {
-		panic("no metadata found")
+		return false, errAborted
 	}
 
-	return strings.Contains("yesYes", res)
+	return strings.Contains("yesYes", res), nil
 }
 
-func requestSearch() string {
+func requestSearch() (string, error) {
 	prompt := promptui.Prompt{
 		Label: "Search for",
 	}
 
 	res, err := prompt.Run()
-
 	if err != nil {
-		panic("aborting")
-	}
-
-	return res
-}
-
-func getUniqueKey(key string) string {
-	path := libraryPath()
-	mark := 'a'
-	valid := key
-
-	for _, err := os.Stat(filepath.Join(path, valid)); !os.IsNotExist(err); _, err = os.Stat(filepath.Join(path, valid)) {
-		valid = fmt.Sprintf("%s%s", key, string(mark))
-		mark++
+		return "", errAborted
 	}
 
-	return valid
+	return res, nil
 }
 
-func commit(entry *scholar.Entry) {
-	entry.Key = getUniqueKey(entry.GetKey())
-	saveTo := filepath.Join(libraryPath(), entry.Key)
-
-	err := os.MkdirAll(saveTo, os.ModePerm)
+func commit(entry *scholar.Entry) error {
+	lib, err := library()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	entry.Key = lib.UniqueKey(entry.GetKey())
 
-	d, err := yaml.Marshal(entry)
-	if err != nil {
-		panic(err)
+	if err := lib.Put(entry); err != nil {
+		return err
 	}
 
-	file := filepath.Join(saveTo, "entry.yaml")
-	ioutil.WriteFile(file, d, 0644)
-	info.println("  .. entry at:", file)
+	info.println("  .. entry at:", filepath.Join(lib.Dir(entry.Key), scholar.EntryFile))
+	return nil
 }
 
-func doiFromPDF(file string) string {
+func doiFromPDF(file string) (string, error) {
 	doi := ""
 	if filepath.Ext(file) != ".pdf" {
-		return doi
+		return doi, nil
 	}
 	if !cmdExists("pdftotext") {
-		return doi
+		return doi, nil
 	}
 	cmd := exec.Command("pdftotext", file, "-")
 	text, err := cmd.Output()
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("pdftotext %s: %w", file, err)
 	}
 	r := bytes.NewBuffer(text)
 	for {
@@ -211,7 +224,7 @@ func doiFromPDF(file string) string {
 		}
 	}
 
-	return doi
+	return doi, nil
 }
 
 func cmdExists(cmd string) bool {
@@ -222,7 +235,7 @@ func cmdExists(cmd string) bool {
 	return true
 }
 
-func query(search string) string {
+func query(search string) (string, error) {
 	info.println("Searching metadata for:", search)
 
 	client := crossref.NewClient("Scholar", viper.GetString("GENERAL.mailto"))
@@ -232,14 +245,14 @@ func query(search string) string {
 		if err == crossref.ErrZeroWorks ||
 			err == crossref.ErrEmptyQuery {
 			info.println("Nothing found...")
-			return ""
+			return "", nil
 		}
 
-		panic(err)
+		return "", fmt.Errorf("search %q: %w: %v", search, scholar.ErrNetwork, err)
 	}
 
 	if len(ws) == 1 {
-		return ws[0].DOI
+		return ws[0].DOI, nil
 	}
 
 	type work struct {
@@ -299,10 +312,10 @@ func query(search string) string {
 	i, _, err := prompt.Run()
 
 	if err != nil {
-		os.Exit(1)
+		return "", errAborted
 	}
 
-	return works[i].DOI
+	return works[i].DOI, nil
 }
 
 func fetchDOI(doi string) (*scholar.Entry, error) {
@@ -310,15 +323,13 @@ func fetchDOI(doi string) (*scholar.Entry, error) {
 
 	w, err := client.DOI(doi)
 	if err != nil {
-		return &scholar.Entry{}, err
+		return &scholar.Entry{}, fmt.Errorf("fetch %s: %w: %v", doi, scholar.ErrNetwork, err)
 	}
 
-	e := parseCrossref(w)
-
-	return e, nil
+	return parseCrossref(w)
 }
 
-func selectType() string {
+func selectType() (string, error) {
 	entries := []*scholar.EntryType{}
 
 	var eNames []string
@@ -363,17 +374,16 @@ func selectType() string {
 	i, _, err := prompt.Run()
 
 	if err != nil {
-		fmt.Println("Aborting")
-		os.Exit(1)
+		return "", errAborted
 	}
 
-	return entries[i].Type
+	return entries[i].Type, nil
 }
 
-func add(entryType string) *scholar.Entry {
+func add(entryType string) (*scholar.Entry, error) {
 	entry, err := scholar.NewEntry(entryType)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	reader := bufio.NewReader(os.Stdin)
@@ -384,49 +394,115 @@ func add(entryType string) *scholar.Entry {
 		entry.Required[field] = text
 	}
 
-	return entry
+	return entry, nil
 }
 
-func attach(entry *scholar.Entry, file string) {
-	key := entry.GetKey()
-	saveTo := filepath.Join(libraryPath(), key)
+// attachments parses and expands the files given as [ROLE=]PATH. A file that
+// exists is never parsed, even if its name has a '='. It returns an error if a
+// file does not exist.
+func attachments(files []string) ([]scholar.Attachment, error) {
+	var as []scholar.Attachment
+	for _, f := range files {
+		a := scholar.ParseAttachment(f)
+		if path, err := homedir.Expand(f); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				a = scholar.Attachment{Path: f}
+			}
+		}
+		path, err := homedir.Expand(a.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, errorf(exitNotFound, "file '%s' not found", path)
+		}
+		a.Path = path
+		as = append(as, a)
+	}
+	return as, nil
+}
 
-	src, err := os.Open(file)
-	defer src.Close()
+// attach copies the files to the directory of the entry and adds them to its
+// list of attached files.
+func attach(entry *scholar.Entry, files ...scholar.Attachment) error {
+	if len(files) == 0 {
+		return nil
+	}
+	lib, err := library()
 	if err != nil {
-		fmt.Println("Attempted to:")
-		fmt.Println(" ", err)
-		return
+		return err
 	}
+	saveTo := lib.Dir(entry.GetKey())
 
-	filename := fmt.Sprintf("%s_%.40s%s", key, clean(entry.Required["title"]), filepath.Ext(file))
+	for _, a := range files {
+		info.println("  .. attaching:", a.Path)
+		filename, err := copyFile(a.Path, saveTo, attachName(entry, a))
+		if err != nil {
+			fmt.Println("Attempted to:")
+			fmt.Println(" ", err)
+			continue
+		}
+		a.Path = filename
+		entry.Attach(a)
+	}
 
-	path := filepath.Join(saveTo, filename)
+	return update(entry)
+}
 
+// attachName returns the name of an attached file, based on the key of the
+// entry, the role of the file, and the title of the entry.
+func attachName(entry *scholar.Entry, a scholar.Attachment) string {
+	name := entry.GetKey()
+	if a.Role != "" {
+		name += "_" + clean(a.Role)
+	}
+	return fmt.Sprintf("%s_%.40s%s", name, clean(entry.Required["title"]), filepath.Ext(a.Path))
+}
+
+// copyFile copies file to dir as filename. If filename already exists, a
+// number is appended to it. It returns the name of the copied file.
+func copyFile(file, dir, filename string) (string, error) {
+	src, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, filename)); os.IsNotExist(err) {
+			break
+		}
+		filename = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+
+	path := filepath.Join(dir, filename)
 	dst, err := os.Create(path)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 	defer dst.Close()
 
 	b, err := io.Copy(dst, src)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 	info.println("     └─ copied", b, "bytes to:", path)
-	// horrible placeholder
-	entry.Attach(filename)
 
-	update(entry)
+	return filename, nil
 }
 
-func manual() *scholar.Entry {
+func manual() (*scholar.Entry, error) {
 	if !isInteractive() {
-		panic("no metadata found")
+		return nil, errorf(exitNotFound, "no metadata found")
 	}
 	info.println("Adding the entry manually...")
 	info.println("Select the type of entry:")
-	t := selectType()
+	t, err := selectType()
+	if err != nil {
+		return nil, err
+	}
 	info.println("Required fields:")
 	return add(t)
 }
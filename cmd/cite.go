@@ -0,0 +1,153 @@
+// Copyright © 2018 Eiji Onchi <eiji@onchi.me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cgxeiji/scholar/scholar"
+	"github.com/spf13/cobra"
+)
+
+// citeCmd represents the cite command
+var citeCmd = &cobra.Command{
+	Use:   "cite [KEY...]",
+	Short: "Print formatted references",
+	Long: `Scholar: a CLI Reference Manager
+
+Print the references of entries formatted with a citation style, ready to be
+pasted in an email, a slide, or a README.
+
+To print the reference of entries by key run:
+
+	scholar cite KEY1 KEY2
+
+If no key is given, an entry is selected from the library in interactive mode.
+
+To choose the citation style (apa, chicago, harvard, or ieee) run:
+
+	scholar cite --style ieee KEY
+
+To use a CSL 1.0 style file instead run:
+
+	scholar cite --csl ./nature.csl KEY
+
+To print an in-text citation, such as "(Last, 2006)", instead of the
+references run:
+
+	scholar cite --citation KEY1 KEY2
+
+To write the references as Markdown or HTML run:
+
+	scholar cite --format markdown KEY
+	scholar cite --format html KEY
+
+To list the available citation styles run:
+
+	scholar cite --list-styles
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if citeListStyles {
+			for _, s := range scholar.Styles() {
+				fmt.Println(s)
+			}
+			return nil
+		}
+		return cite(args)
+	},
+}
+
+var citeStyle string
+var citeFormat string
+var citeListStyles bool
+var citeCSL string
+var citeCitation bool
+
+func init() {
+	rootCmd.AddCommand(citeCmd)
+
+	citeCmd.Flags().StringVarP(&citeStyle, "style", "s", "apa", "citation style (see --list-styles)")
+	citeCmd.Flags().StringVarP(&citeFormat, "format", "f", "text", "output format: text, markdown, or html")
+	citeCmd.Flags().StringVar(&citeCSL, "csl", "", "CSL style `FILE` to use instead of --style")
+	citeCmd.Flags().BoolVarP(&citeCitation, "citation", "c", false, "print an in-text citation instead of the references")
+	citeCmd.Flags().BoolVar(&citeListStyles, "list-styles", false, "list the available citation styles")
+}
+
+func cite(keys []string) error {
+	markup, err := scholar.ParseMarkup(citeFormat)
+	if err != nil {
+		return err
+	}
+
+	style := citeStyle
+	if citeCSL != "" {
+		csl, err := scholar.LoadCSL(citeCSL)
+		if err != nil {
+			return err
+		}
+		// The absolute path of the file cannot be the name of a built-in
+		// style
+		if style, err = filepath.Abs(citeCSL); err != nil {
+			return err
+		}
+		scholar.RegisterStyle(style, csl)
+	}
+
+	var entries []*scholar.Entry
+	if len(keys) == 0 {
+		if !isInteractive() {
+			return errorf(exitUsage, "no key given\nplease, give the keys of the entries to cite")
+		}
+		entry, err := queryEntry(keys)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	lib, err := library()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		entry, err := lib.Get(key)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	if citeCitation {
+		out, err := scholar.Citation(entries, style, markup)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+
+	out, err := scholar.Bibliography(entries, style, markup)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
@@ -51,8 +51,8 @@ a configuration file will be created at:
 TODO: add option to create a local configuration
 --------------------------------------------------------------------------------
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		configure()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return configure()
 	},
 }
 
@@ -60,7 +60,7 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 }
 
-func configure() {
+func configure() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
@@ -77,13 +77,13 @@ func configure() {
 		path, _ := homedir.Dir()
 		path = filepath.Join(path, ".config", "scholar", "config.yaml")
 		if err := ioutil.WriteFile(path, configTemplate, 0644); err != nil {
-			panic(nil)
+			return err
 		}
 
 		if err := viper.ReadInConfig(); err != nil {
-			panic(nil)
+			return err
 		}
 	}
 
-	editor(viper.ConfigFileUsed())
+	return editor(viper.ConfigFileUsed())
 }
@@ -0,0 +1,186 @@
+// Copyright © 2018 Eiji Onchi <eiji@onchi.me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cgxeiji/scholar/scholar"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find duplicated entries",
+	Long: `Scholar: a CLI Reference Manager
+
+Find entries that are likely the same work and print them in groups, with the
+confidence that they are duplicates. Entries are compared by DOI, ISBN, title,
+authors, and year. Editions of a book years apart, and volumes of a work, are
+not reported as duplicates.
+
+To only show the most likely duplicates run:
+
+	scholar dedupe --threshold 0.95
+
+To merge the entries of each group run:
+
+	scholar dedupe --merge
+
+In interactive mode, the entry to keep is selected for each group, and groups
+can be skipped. Otherwise, the entries are merged into the first entry of the
+group (see scholar merge --help).
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		policy, ok := mergePolicies[dedupeKeep]
+		if !ok {
+			return errorf(exitUsage, "invalid value %q for --keep: must be first, second, or longer", dedupeKeep)
+		}
+		if dedupeThreshold <= 0 || dedupeThreshold > 1 {
+			return errorf(exitUsage, "invalid value %v for --threshold: must be greater than 0 and at most 1", dedupeThreshold)
+		}
+		return dedupe(policy)
+	},
+}
+
+var dedupeThreshold float64
+var dedupeMerge bool
+var dedupeKeep string
+
+// duplicateThreshold is the similarity from which two entries are probable
+// duplicates.
+const duplicateThreshold = 0.8
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	dedupeCmd.Flags().Float64VarP(&dedupeThreshold, "threshold", "t", duplicateThreshold, "minimum confidence, from 0 to 1, of the duplicates")
+	dedupeCmd.Flags().BoolVarP(&dedupeMerge, "merge", "m", false, "merge the entries of each group")
+	dedupeCmd.Flags().StringVarP(&dedupeKeep, "keep", "k", "first", "value to keep on merge conflicts: first, second, or longer")
+}
+
+func dedupe(policy scholar.MergePolicy) error {
+	entries, err := entryList()
+	if err != nil {
+		return err
+	}
+
+	ds := scholar.FindDuplicates(entries, dedupeThreshold)
+	if len(ds) == 0 {
+		info.println("No duplicates found")
+		return nil
+	}
+
+	for _, d := range ds {
+		var keys []string
+		for _, e := range d.Entries {
+			keys = append(keys, e.GetKey())
+		}
+		fmt.Printf("%.0f%% %s\n", d.Score*100, strings.Join(keys, " "))
+		for _, e := range d.Entries {
+			fmt.Printf("  %s: %s (%s) %s\n", e.GetKey(), e.FirstAuthorLast(), e.Year(), e.Required["title"])
+		}
+
+		if !dedupeMerge {
+			continue
+		}
+		winner := keys[0]
+		if isInteractive() {
+			if winner, err = selectKey(keys); err != nil {
+				return err
+			}
+			if winner == "" {
+				continue
+			}
+		}
+		for _, loser := range keys {
+			if loser == winner {
+				continue
+			}
+			if err := merge(winner, loser, policy); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectKey asks which entry of a group of duplicates to keep. It returns an
+// empty key if the group is skipped.
+func selectKey(keys []string) (string, error) {
+	prompt := promptui.Select{
+		Label: "Merge into",
+		Items: append(append([]string{}, keys...), "skip"),
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", errAborted
+	}
+	if i == len(keys) {
+		return "", nil
+	}
+
+	return keys[i], nil
+}
+
+// warnDuplicates prints a warning for each entry that is a probable duplicate
+// of e. It returns true if a duplicate was found.
+func warnDuplicates(e *scholar.Entry, entries []*scholar.Entry) bool {
+	found := false
+	for _, d := range entries {
+		if s := scholar.Similarity(e, d); s >= duplicateThreshold {
+			fmt.Fprintf(os.Stderr, "warning: %q is a probable duplicate of %s (%.0f%%)\n", e.Required["title"], d.GetKey(), s*100)
+			found = true
+		}
+	}
+	return found
+}
+
+// checkDuplicates warns if e is a probable duplicate of an entry of the
+// library. In interactive mode, it asks whether to add e anyway.
+func checkDuplicates(e *scholar.Entry) error {
+	lib, err := library()
+	if err != nil {
+		return err
+	}
+	entries, err := libraryEntries(lib)
+	if err != nil {
+		return err
+	}
+	if !warnDuplicates(e, entries) || !isInteractive() {
+		return nil
+	}
+
+	ok, err := askYesNo("Add it anyway?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errAborted
+	}
+	return nil
+}
@@ -33,25 +33,37 @@ var editCmd = &cobra.Command{
 	Long: `Scholar: a CLI Reference Manager
 
 Edit an entry's metadata using the default's text editor.
+
+The entry is validated after it is saved. If it has invalid fields, the
+problems are printed and scholar exits with status 7.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		if entry := queryEntry(args); entry != nil {
-			if attachFlag != "" {
-				attach(entry, attachFlag)
-				return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entry, err := queryEntry(args)
+		if err != nil {
+			return err
+		}
+		if len(attachFlag) > 0 {
+			as, err := attachments(attachFlag)
+			if err != nil {
+				return err
+			}
+			return attach(entry, as...)
+		}
+		if editType != "" {
+			if entry, err = scholar.Convert(entry, editType); err != nil {
+				return err
 			}
-			if editType != "" {
-				var err error
-				entry, err = scholar.Convert(entry, editType)
-				if err != nil {
-					panic(err)
-				}
-				update(entry)
+			if err := update(entry); err != nil {
+				return err
 			}
-			edit(entry)
-		} else {
-			panic("entry not found")
 		}
+		if err := edit(entry); err != nil {
+			return err
+		}
+		if !checkEntry(entry) {
+			return errorf(exitInvalid, "%s was saved with invalid fields", entry.GetKey())
+		}
+		return nil
 	},
 }
 
@@ -60,6 +72,6 @@ var editType string
 func init() {
 	rootCmd.AddCommand(editCmd)
 
-	editCmd.Flags().StringVarP(&attachFlag, "attach", "a", "", "attach a file to the entry")
+	editCmd.Flags().StringArrayVarP(&attachFlag, "attach", "a", nil, "attach a file to the entry as [ROLE=]PATH (can be repeated)")
 	editCmd.Flags().StringVarP(&editType, "type", "t", "", "change the type of the entry")
 }
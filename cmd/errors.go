@@ -0,0 +1,133 @@
+// Copyright © 2018 Eiji Onchi <eiji@onchi.me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cgxeiji/scholar/scholar"
+)
+
+// Exit codes of scholar. They are documented in the help of the root command.
+const (
+	exitOK        = 0
+	exitError     = 1 // unexpected error
+	exitUsage     = 2 // invalid command, flag, or argument
+	exitNotFound  = 3 // no entry, file, type, format, or style found
+	exitAmbiguous = 4 // the search matched more than one entry
+	exitLibrary   = 5 // library not found or not configured
+	exitNetwork   = 6 // metadata could not be fetched
+	exitInvalid   = 7 // invalid entry or input file
+	exitIO        = 8 // a file could not be read or written
+	exitAborted   = 9 // canceled by the user
+)
+
+var exitKinds = map[int]string{
+	exitError:     "error",
+	exitUsage:     "usage",
+	exitNotFound:  "not_found",
+	exitAmbiguous: "ambiguous",
+	exitLibrary:   "library_not_found",
+	exitNetwork:   "network",
+	exitInvalid:   "invalid",
+	exitIO:        "io",
+	exitAborted:   "aborted",
+}
+
+// cliError is an error with the exit code of the command.
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+func errorf(code int, format string, a ...interface{}) error {
+	return &cliError{code: code, err: fmt.Errorf(format, a...)}
+}
+
+var (
+	errNoEntries = errorf(exitNotFound, "no entries found")
+	errAborted   = errorf(exitAborted, "aborted")
+)
+
+// exitCode returns the exit code of an error.
+func exitCode(err error) int {
+	var ce *cliError
+	var pe *os.PathError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, scholar.ErrLibraryNotFound):
+		return exitLibrary
+	case errors.Is(err, scholar.ErrNetwork):
+		return exitNetwork
+	case errors.Is(err, scholar.ErrInvalidEntry),
+		errors.Is(err, scholar.ErrParse),
+		errors.Is(err, scholar.ErrInvalidTemplate):
+		return exitInvalid
+	case errors.Is(err, scholar.ErrEntryNotFound),
+		errors.Is(err, scholar.ErrTypeNotFound),
+		errors.Is(err, scholar.ErrFieldNotFound),
+		errors.Is(err, scholar.ErrFormatNotFound),
+		errors.Is(err, scholar.ErrStyleNotFound):
+		return exitNotFound
+	case errors.Is(err, scholar.ErrIO), errors.As(err, &pe):
+		return exitIO
+	}
+	return exitError
+}
+
+// report prints the error to stderr, as a JSON object if --json is set, and
+// returns its exit code.
+func report(err error) int {
+	code := exitCode(err)
+	if jsonErrors {
+		out := struct {
+			Error struct {
+				Code    int    `json:"code"`
+				Kind    string `json:"kind"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		out.Error.Code = code
+		out.Error.Kind = exitKinds[code]
+		out.Error.Message = err.Error()
+		json.NewEncoder(os.Stderr).Encode(out)
+		return code
+	}
+
+	info.error(err)
+	if code == exitUsage {
+		fmt.Fprintln(os.Stderr, "Run 'scholar help' for usage.")
+	}
+	return code
+}
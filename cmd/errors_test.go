@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cgxeiji/scholar/scholar"
+)
+
+func TestExitCode(t *testing.T) {
+	_, formatErr := scholar.Import(strings.NewReader(""), "unknown")
+	_, pathErr := os.Open("does-not-exist")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"unknown", errors.New("unknown"), exitError},
+		{"usage", errorf(exitUsage, "no key given"), exitUsage},
+		{"wrapped cli error", fmt.Errorf("cite: %w", errAborted), exitAborted},
+		{"no entries", errNoEntries, exitNotFound},
+		{"library not found", fmt.Errorf("open: %w", scholar.ErrLibraryNotFound), exitLibrary},
+		{"network", scholar.ErrNetwork, exitNetwork},
+		{"invalid entry", scholar.ErrInvalidEntry, exitInvalid},
+		{"parse", scholar.ErrParse, exitInvalid},
+		{"invalid template", scholar.ErrInvalidTemplate, exitInvalid},
+		{"entry not found", scholar.ErrEntryNotFound, exitNotFound},
+		{"type not found", scholar.ErrTypeNotFound, exitNotFound},
+		{"field not found", scholar.ErrFieldNotFound, exitNotFound},
+		{"format not found", formatErr, exitNotFound},
+		{"style not found", scholar.ErrStyleNotFound, exitNotFound},
+		{"io", scholar.ErrIO, exitIO},
+		{"path error", pathErr, exitIO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	stderr := os.Stderr
+	defer func() {
+		os.Stderr = stderr
+		jsonErrors = false
+	}()
+
+	tests := []struct {
+		name string
+		json bool
+		err  error
+		want string
+		code int
+	}{
+		{"text", false, errNoEntries, "error: no entries found\n", exitNotFound},
+		{"usage", false, errorf(exitUsage, "no file to import"), "error: no file to import\nRun 'scholar help' for usage.\n", exitUsage},
+		{"json", true, errorf(exitAmbiguous, "too many entries"), `{"error":{"code":4,"kind":"ambiguous","message":"too many entries"}}` + "\n", exitAmbiguous},
+		{"json unknown", true, errors.New("boom"), `{"error":{"code":1,"kind":"error","message":"boom"}}` + "\n", exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "stderr")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			os.Stderr = f
+			jsonErrors = tt.json
+
+			if code := report(tt.err); code != tt.code {
+				t.Errorf("report(%v) = %d, want %d", tt.err, code, tt.code)
+			}
+			got, err := ioutil.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("report(%v) printed %q, want %q", tt.err, got, tt.want)
+			}
+			if tt.json && !json.Valid(got) {
+				t.Errorf("report(%v) printed invalid JSON %q", tt.err, got)
+			}
+		})
+	}
+}
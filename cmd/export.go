@@ -22,13 +22,12 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/cgxeiji/scholar/scholar"
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // exportCmd represents the export command
@@ -47,63 +46,138 @@ To specify which entries to export run:
 
 	scholar export SEARCH TERM
 
---------------------------------------------------------------------------------
-TODO: add more export formats
---------------------------------------------------------------------------------
+To validate the entries before exporting run:
+
+	scholar export --strict
+
+If any entry has an invalid field, nothing is exported.
+
+To write accented letters as LaTeX commands, such as {\'e} for é, in the
+bibtex and biblatex formats run:
+
+	scholar export --format bibtex --ascii
+
+Attached files are written with their full path. To list the available export
+formats run:
+
+	scholar export --list-formats
+
+Custom formats are loaded from the *.tmpl files in ~/.config/scholar/formats/,
+whichever configuration file is used. Each file is a Go text template executed
+with each entry, and can define "header" and "footer" templates. A template
+named like a built-in format, such as bibtex.tmpl, is skipped with a warning. For example, ~/.config/scholar/formats/report.tmpl:
+
+	{{ define "header" }}Publications ({{ len . }}){{ "\n" }}{{ end -}}
+	- {{ join ", " (fullnames . "author") }}. {{ field . "title" }}. {{ year . }}.
+
+is used by running:
+
+	scholar export --format report
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		export(args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadFormats(); err != nil {
+			return err
+		}
+		if exportListFormats {
+			for _, f := range scholar.Formats() {
+				fmt.Println(f)
+			}
+			return nil
+		}
+		return export(args)
 	},
 }
 
 var exportFormat string
+var exportStrict bool
+var exportListFormats bool
+var exportASCII bool
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
 
-	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "biblatex", "Specify the export format (avail: biblatex, bibtex, ris)")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "biblatex", "Specify the export format (see --list-formats)")
+	exportCmd.Flags().BoolVar(&exportStrict, "strict", false, "validate all entries before exporting")
+	exportCmd.Flags().BoolVar(&exportListFormats, "list-formats", false, "list the available export formats")
+	exportCmd.Flags().BoolVar(&exportASCII, "ascii", false, "write accented letters as LaTeX commands (bibtex and biblatex)")
 }
 
-func export(args []string) {
-	if len(args) != 0 {
-		if found := guiSearch(args, entryList(), searcher); len(found) != 0 {
-			for _, e := range found {
-				fmt.Println(e.Export(exportFormat))
-				if exportFormat != "ris" {
-					fmt.Println()
-				}
-			}
-		}
-		return
+// loadFormats registers the export formats defined by the templates of
+// ~/.config/scholar/formats. Templates named like a registered format are
+// skipped.
+func loadFormats() error {
+	home, err := homedir.Dir()
+	if err != nil {
+		return err
+	}
+	ts, err := scholar.LoadTemplates(filepath.Join(home, ".config", "scholar", "formats"))
+	if err != nil {
+		return err
 	}
 
-	path := libraryPath()
-	if currentLibrary != "" {
-		path = viper.Sub("LIBRARIES").GetString(currentLibrary)
+	registered := make(map[string]bool)
+	for _, f := range scholar.Formats() {
+		registered[f] = true
+	}
+	for _, t := range ts {
+		if registered[t.Name()] {
+			fmt.Fprintf(os.Stderr, "warning: template %s.tmpl is skipped, %q is a built-in format\n", t.Name(), t.Name())
+			continue
+		}
+		scholar.RegisterExporter(t.Name(), t)
 	}
+	return nil
+}
 
-	dirs, err := ioutil.ReadDir(path)
+func export(args []string) error {
+	entries, err := entryList()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	for _, dir := range dirs {
-		if dir.IsDir() {
-			d, err := ioutil.ReadFile(filepath.Join(path, dir.Name(), "entry.yaml"))
-			if err != nil {
-				panic(err)
+	if exportStrict {
+		valid := true
+		for _, e := range entries {
+			if matchAll(args, e) {
+				valid = checkEntry(e) && valid
 			}
+		}
+		if !valid {
+			return errorf(exitInvalid, "invalid entries found")
+		}
+	}
 
-			var e scholar.Entry
-			err = yaml.Unmarshal(d, &e)
-			if err != nil {
-				panic(err)
+	var opts []scholar.ExportOption
+	if exportASCII {
+		opts = append(opts, scholar.ASCII())
+	}
+	ew, err := scholar.NewExportWriter(os.Stdout, exportFormat, opts...)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !matchAll(args, e) {
+			continue
+		}
+		// Exported files are read outside of the library
+		for i, a := range e.Files {
+			if e.Files[i].Path, err = attachmentPath(e, a); err != nil {
+				return err
 			}
+		}
+		if err := ew.Write(e); err != nil {
+			return err
+		}
+	}
+	return ew.Close()
+}
 
-			fmt.Println(e.Export(exportFormat))
-			if exportFormat != "ris" {
-				fmt.Println()
-			}
+// matchAll reports whether the entry matches all the search terms.
+func matchAll(search []string, e *scholar.Entry) bool {
+	for _, key := range search {
+		if !searcher(key, e) {
+			return false
 		}
 	}
+	return true
 }
@@ -16,7 +16,6 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
@@ -28,27 +27,50 @@ var fetchCmd = &cobra.Command{
 	Long: `Scholar: a CLI Reference Manager
 
 Fetch the file path attached to an entry and print the result to stdout.
-If no file is attached, it exists with 1.
+If there are multiple files attached, the first one is printed, unless a role
+is specified with --role or all files are requested with --all.
+If no file is attached, it exits with 3.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		if entry := queryEntry(args); entry != nil {
-			if entry.File != "" {
-				fmt.Println(filepath.Join(libraryPath(), entry.GetKey(), entry.File))
-			} else if url, ok := entry.Optional["url"]; ok && url != "" {
-				fmt.Println(url)
-			} else if url, ok := entry.Required["url"]; ok && url != "" {
-				fmt.Println(url)
-			} else if doi, ok := entry.Optional["doi"]; ok && doi != "" {
-				fmt.Println(fmt.Sprintf("https://dx.doi.org/%s", doi))
-			} else {
-				panic("no file, doi, or url associated with entry")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entry, err := queryEntry(args)
+		if err != nil {
+			return err
+		}
+		if fetchAll && len(entry.Files) > 0 {
+			for _, a := range entry.Files {
+				path, err := attachmentPath(entry, a)
+				if err != nil {
+					return err
+				}
+				fmt.Println(path)
+			}
+		} else if a, ok := entry.Attachment(fetchRole); ok {
+			path, err := attachmentPath(entry, a)
+			if err != nil {
+				return err
 			}
+			fmt.Println(path)
+		} else if fetchRole != "" {
+			return errorf(exitNotFound, "no file with role '%s' attached to entry", fetchRole)
+		} else if url, ok := entry.Optional["url"]; ok && url != "" {
+			fmt.Println(url)
+		} else if url, ok := entry.Required["url"]; ok && url != "" {
+			fmt.Println(url)
+		} else if doi, ok := entry.Optional["doi"]; ok && doi != "" {
+			fmt.Println(fmt.Sprintf("https://dx.doi.org/%s", doi))
 		} else {
-			panic("entry not found")
+			return errorf(exitNotFound, "no file, doi, or url associated with entry")
 		}
+		return nil
 	},
 }
 
+var fetchRole string
+var fetchAll bool
+
 func init() {
 	rootCmd.AddCommand(fetchCmd)
+
+	fetchCmd.Flags().StringVarP(&fetchRole, "role", "r", "", "print the file attached with this role")
+	fetchCmd.Flags().BoolVar(&fetchAll, "all", false, "print all the attached files")
 }
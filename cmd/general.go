@@ -22,7 +22,6 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,47 +29,35 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/cgxeiji/crossref"
 	"github.com/cgxeiji/scholar/scholar"
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/viper"
-	yaml "gopkg.in/yaml.v2"
 )
 
-func edit(entry *scholar.Entry) {
-	key := entry.GetKey()
-	saveTo := filepath.Join(libraryPath(), key)
-
-	file := filepath.Join(saveTo, "entry.yaml")
-
-	err := editor(file)
+func edit(entry *scholar.Entry) error {
+	lib, err := library()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	key := entry.GetKey()
 
-	d, err := ioutil.ReadFile(file)
+	e, err := lib.Edit(key, editor)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	*entry = *e
 
-	yaml.Unmarshal(d, &entry)
-
-	checkDirKey(libraryPath(), key, entry)
+	return checkDirKey(key, entry)
 }
 
-func update(entry *scholar.Entry) {
-	key := entry.GetKey()
-	saveTo := filepath.Join(libraryPath(), key)
-
-	file := filepath.Join(saveTo, "entry.yaml")
-
-	d, err := yaml.Marshal(entry)
+func update(entry *scholar.Entry) error {
+	lib, err := library()
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	ioutil.WriteFile(file, d, 0644)
+	return lib.Put(entry)
 }
 
 func editor(file string) error {
@@ -111,6 +98,48 @@ func open(file string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
+// attachmentPath returns the full path of a file attached to an entry.
+func attachmentPath(e *scholar.Entry, a scholar.Attachment) (string, error) {
+	if filepath.IsAbs(a.Path) {
+		return a.Path, nil
+	}
+	lib, err := library()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(lib.Dir(e.GetKey()), a.Path), nil
+}
+
+// selectAttachment returns the file attached to an entry with the given role.
+// If role is empty and there are multiple files attached, a selection menu
+// appears in interactive mode, otherwise the first file is returned.
+func selectAttachment(e *scholar.Entry, role string) (scholar.Attachment, bool, error) {
+	if role != "" || len(e.Files) < 2 || !isInteractive() {
+		a, ok := e.Attachment(role)
+		return a, ok, nil
+	}
+
+	template := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "> {{ .Path | yellow | bold | underline }} {{ .Role | cyan }} {{ .Description | faint }}",
+		Inactive: "  {{ .Path | yellow }} {{ .Role | cyan }} {{ .Description | faint }}",
+		Selected: "Opening: {{ .Path | cyan | bold }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select a file:",
+		Items:     e.Files,
+		Templates: template,
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return scholar.Attachment{}, false, errAborted
+	}
+
+	return e.Files[i], true, nil
+}
+
 func clean(filename string) string {
 	rx, err := regexp.Compile("[^[:alnum:][:space:]]+")
 	if err != nil {
@@ -123,154 +152,154 @@ func clean(filename string) string {
 	return strings.ToLower(filename)
 }
 
-func libraryPath() string {
+func libraryPath() (string, error) {
+	libraries := viper.Sub("LIBRARIES")
+	if libraries == nil {
+		return "", errorf(exitLibrary, "no libraries configured\nrun 'scholar config' to add one")
+	}
 	if currentLibrary != "" {
-		if !viper.Sub("LIBRARIES").IsSet(currentLibrary) {
-			fmt.Println("No library called", currentLibrary, "was found!")
-			fmt.Println("Available libraries:")
+		if !libraries.IsSet(currentLibrary) {
+			b := new(strings.Builder)
 			for k, v := range viper.GetStringMapString("LIBRARIES") {
-				fmt.Println(" ", k)
-				fmt.Println("   ", v)
+				fmt.Fprintf(b, "\n  %s\n    %s", k, v)
 			}
-			panic("not found: library")
+			return "", errorf(exitLibrary, "no library called %s was found\navailable libraries:%s", currentLibrary, b)
 		}
 
-		return viper.Sub("LIBRARIES").GetString(currentLibrary)
+		return libraries.GetString(currentLibrary), nil
 	}
-	return viper.Sub("LIBRARIES").GetString(viper.GetString("GENERAL.default"))
+	return libraries.GetString(viper.GetString("GENERAL.default")), nil
 }
 
+// isInteractive checks if prompts can be shown. Interactive mode is set in the
+// configuration file and toggled with --interactive, but it is always
+// disabled when stdin or stdout is not a terminal.
 func isInteractive() bool {
-	return viper.GetBool("GENERAL.interactive") != viper.GetBool("interactive")
+	return viper.GetBool("GENERAL.interactive") != viper.GetBool("interactive") &&
+		isTerminal(os.Stdin) && isTerminal(os.Stdout)
 }
 
-func entryList() []*scholar.Entry {
-	path := libraryPath()
-	dirs, err := ioutil.ReadDir(path)
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func library() (*scholar.Library, error) {
+	path, err := libraryPath()
+	if err != nil {
+		return nil, err
+	}
+	return scholar.Open(path)
+}
+
+// entryList returns the entries of the library. The directories of entries
+// whose key was edited by hand are renamed first (see Library.Fix).
+func entryList() ([]*scholar.Entry, error) {
+	lib, err := library()
+	if err != nil {
+		return nil, err
+	}
+	renamed, err := lib.Fix()
 	if err != nil {
-		fmt.Println(err)
-		fmt.Println(`
+		return nil, fmt.Errorf(`%w
+
 Add an entry to create this directory or run:
 
 	scholar config
 
-to set the correct path of this library.`,
-		)
-		panic("not found: library path")
+to set the correct path of this library`, err)
+	}
+	for _, e := range renamed {
+		info.println("Renamed:", lib.Dir(e.GetKey()))
 	}
 
-	var wg sync.WaitGroup
-
-	entries := []*scholar.Entry{}
-	queue := make(chan *scholar.Entry)
-	done := make(chan bool)
+	return lib.List()
+}
 
-	go func() {
-		defer close(done)
-		for e := range queue {
-			entries = append(entries, e)
-		}
-	}()
-
-	wg.Add(len(dirs))
-	for _, dir := range dirs {
-		dir := dir
-		go func() {
-			defer wg.Done()
-			if dir.IsDir() {
-				filename := filepath.Join(path, dir.Name(), "entry.yaml")
-				d, err := ioutil.ReadFile(filename)
-				if err != nil {
-					fmt.Println("Could not find data for:", dir.Name())
-					return
-				}
-				var e scholar.Entry
-				if err := yaml.Unmarshal(d, &e); err != nil {
-					panic(fmt.Errorf("%s -- in directory %s",
-						err.Error(), dir.Name()))
-				}
-
-				checkDirKey(path, dir.Name(), &e)
-
-				info, err := os.Stat(filename)
-				if err != nil {
-					fmt.Println("Could not find metadata for:", dir.Name())
-					return
-				}
-				e.Info = info
-
-				queue <- &e
-			}
-		}()
+// libraryEntries returns the entries of the library without changing it, for
+// example, to look for duplicates of new entries. A library that does not
+// exist yet has no entries.
+func libraryEntries(lib *scholar.Library) ([]*scholar.Entry, error) {
+	entries, err := lib.List()
+	if scholar.IsError(scholar.ErrLibraryNotFound, err) {
+		return nil, nil
 	}
-	wg.Wait()
-	close(queue)
-	<-done
-
-	return entries
+	return entries, err
 }
 
 // checkDirKey makes sure the directory name is the same as the entry's key.
-func checkDirKey(path, dir string, e *scholar.Entry) {
+func checkDirKey(dir string, e *scholar.Entry) error {
 	if dir == e.GetKey() {
-		return
+		return nil
+	}
+	lib, err := library()
+	if err != nil {
+		return err
 	}
-	if err := os.Rename(filepath.Join(path, dir), filepath.Join(path, ".tmp.scholar")); err != nil {
-		panic(err)
+	renamed, err := lib.Rename(dir, e.GetKey())
+	if err != nil {
+		return err
 	}
+	*e = *renamed
 
-	e.Key = getUniqueKey(e.GetKey())
+	fmt.Println("Renamed:")
+	fmt.Println(" ", lib.Dir(dir), ">", lib.Dir(e.GetKey()))
+	return nil
+}
 
-	if err := os.Rename(filepath.Join(path, ".tmp.scholar"), filepath.Join(path, e.GetKey())); err != nil {
-		panic(err)
+// checkEntry validates the entry and prints the problems found to stderr. It
+// returns false if the entry has any error.
+func checkEntry(e *scholar.Entry) bool {
+	problems := e.Validate()
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", e.GetKey(), p)
 	}
-	update(e)
-	fmt.Println("Renamed:")
-	fmt.Println(" ", filepath.Join(path, dir), ">",
-		filepath.Join(path, e.GetKey()))
+	return !scholar.HasErrors(problems)
 }
 
-func queryEntry(search []string) *scholar.Entry {
-	var entry *scholar.Entry
-
-	if viper.GetBool("GENERAL.interactive") != viper.GetBool("interactive") {
-		entry = guiQuery(entryList(), search)
-	} else {
-		found := guiSearch(search, entryList(), searcher)
-		switch len(found) {
-		case 0:
-			panic("no entries found")
-		case 1:
-			entry = found[0]
-		default:
-			panic(fmt.Errorf("too many entries (%d) matched\nplease, refine your query", len(found)))
-		}
+// queryEntry returns the entry that matches the search. In interactive mode,
+// the entry is selected from the matches.
+func queryEntry(search []string) (*scholar.Entry, error) {
+	entries, err := entryList()
+	if err != nil {
+		return nil, err
+	}
+
+	if isInteractive() {
+		return guiQuery(entries, search)
 	}
 
-	return entry
+	found := guiSearch(search, entries, searcher)
+	switch len(found) {
+	case 0:
+		return nil, errNoEntries
+	case 1:
+		return found[0], nil
+	}
+	return nil, errorf(exitAmbiguous, "too many entries (%d) matched\nplease, refine your query", len(found))
 }
 
-func parseCrossref(work *crossref.Work) *scholar.Entry {
+func parseCrossref(work *crossref.Work) (*scholar.Entry, error) {
 	var e *scholar.Entry
 	var err error
 
 	switch work.Type {
 	case "journal-article":
 		if e, err = scholar.NewEntry("article"); err != nil {
-			panic(err)
+			return nil, err
 		}
 		e.Required["journaltitle"] = work.BookTitle
 		e.Optional["issn"] = work.ISSN
 	case "proceedings-article":
 		if e, err = scholar.NewEntry("inproceedings"); err != nil {
-			panic(err)
+			return nil, err
 		}
 		e.Required["booktitle"] = work.BookTitle
 		e.Optional["isbn"] = work.ISBN
 		e.Optional["publisher"] = work.Publisher
 	default:
 		if e, err = scholar.NewEntry("article"); err != nil {
-			panic(err)
+			return nil, err
 		}
 		e.Required["journaltitle"] = work.BookTitle
 	}
@@ -280,7 +309,9 @@ func parseCrossref(work *crossref.Work) *scholar.Entry {
 	}
 	e.Required["author"] = strings.TrimSuffix(e.Required["author"], " and ")
 
-	e.Required["date"] = formatDate(work.Date)
+	if e.Required["date"], err = formatDate(work.Date); err != nil {
+		return nil, err
+	}
 	e.Required["title"] = work.Title
 
 	for _, a := range work.Editors {
@@ -294,16 +325,16 @@ func parseCrossref(work *crossref.Work) *scholar.Entry {
 	e.Optional["doi"] = work.DOI
 	e.Optional["abstract"] = work.Abstract
 
-	return e
+	return e, nil
 }
 
-func formatDate(date string) string {
+func formatDate(date string) (string, error) {
 	parts := strings.Split(date, "-")
 	for i := range parts {
 		fixed := ""
 		p, err := strconv.Atoi(parts[i])
 		if err != nil {
-			panic(err)
+			return "", err
 		}
 		if p < 10 {
 			fixed += "0"
@@ -312,5 +343,5 @@ func formatDate(date string) string {
 		parts[i] = fixed
 	}
 
-	return strings.Join(parts, "-")
+	return strings.Join(parts, "-"), nil
 }
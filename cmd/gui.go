@@ -37,10 +37,10 @@ var sortby = []string{"modified", "title", "author", "date"}
 var sortid = 0
 var showList []*scholar.Entry
 
-func guiQuery(entries []*scholar.Entry, search []string) *scholar.Entry {
+func guiQuery(entries []*scholar.Entry, search []string) (*scholar.Entry, error) {
 	g, err := gocui.NewGui(gocui.OutputNormal)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer g.Close()
 
@@ -55,6 +55,9 @@ func guiQuery(entries []*scholar.Entry, search []string) *scholar.Entry {
 	g.SelFgColor = gocui.ColorGreen | gocui.AttrBold
 	g.FgColor = gocui.ColorWhite
 
+	// notFound is set when the initial search has no matches
+	notFound := false
+
 	g.SetManagerFunc(func(g *gocui.Gui) error {
 		maxX, maxY := g.Size()
 
@@ -148,6 +151,7 @@ func guiQuery(entries []*scholar.Entry, search []string) *scholar.Entry {
 			found := guiSearch(search, entries, searcher)
 			switch len(found) {
 			case 0:
+				notFound = true
 				return gocui.ErrQuit
 			case 1:
 				selEntryCh <- found[0]
@@ -199,7 +203,7 @@ func guiQuery(entries []*scholar.Entry, search []string) *scholar.Entry {
 	})
 
 	if err := g.SetKeybinding("main", 'q', gocui.ModNone, quit); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("main", 's', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
@@ -212,20 +216,20 @@ func guiQuery(entries []*scholar.Entry, search []string) *scholar.Entry {
 		}
 		return nil
 	}); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("main", '/', gocui.ModNone, toggleSearch); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("search", gocui.KeyEnter, gocui.ModNone, toggleSearch); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	g.InputEsc = true
 	if err := g.SetKeybinding("search", gocui.KeyEsc, gocui.ModNone, toggleSearch); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("main", gocui.KeyEnter, gocui.ModNone,
@@ -235,27 +239,33 @@ func guiQuery(entries []*scholar.Entry, search []string) *scholar.Entry {
 			selEntryCh <- showList[oy+cy]
 			return gocui.ErrQuit
 		}); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("main", gocui.KeySpace, gocui.ModNone, guiShowInfo); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("info", gocui.KeySpace, gocui.ModNone, guiHideInfo); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
-		panic(err)
+		return nil, err
 	}
 
 	close(selEntryCh)
-	return <-selEntryCh
+	if e := <-selEntryCh; e != nil {
+		return e, nil
+	}
+	if notFound {
+		return nil, errNoEntries
+	}
+	return nil, errAborted
 }
 
 func toggleSearch(g *gocui.Gui, v *gocui.View) error {
@@ -372,12 +382,27 @@ func guiSort(entries []*scholar.Entry, field string) {
 		})
 		return
 	}
+	if field == "author" {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return authorSortKey(entries[i]) < authorSortKey(entries[j])
+		})
+		return
+	}
 
 	sort.SliceStable(entries, func(i, j int) bool {
 		return entries[i].Required[field] < entries[j].Required[field]
 	})
 }
 
+func authorSortKey(entry *scholar.Entry) string {
+	names := entry.Names("author")
+	keys := make([]string, len(names))
+	for i, n := range names {
+		keys[i] = n.SortKey()
+	}
+	return strings.Join(keys, " ")
+}
+
 func formatEntry(entry *scholar.Entry, width int) string {
 	return fmt.Sprintf("\033[32;1m%-*.*s  \033[33;1m(%-4.4s)  \033[31;1m%-*.*s\033[0m\n",
 		width/3*2-4, width/3*2-4, entry.Required["title"],
@@ -391,11 +416,10 @@ func formatEntryInfo(w io.Writer, e *scholar.Entry) {
 		e.GetKey())
 	fmt.Fprintf(w, "Title:\n  \033[32;1m%s\033[0m\n",
 		e.Required["title"])
-	aus := strings.Split(e.Required["author"], " and ")
 	fmt.Fprintf(w, "Author(s):\n")
-	for _, au := range aus {
+	for _, au := range e.Names("author") {
 		fmt.Fprintf(w, "  \033[31;1m%s\033[0m\n",
-			au)
+			au.Full())
 	}
 
 	fmt.Fprintf(w, "Date:\n  \033[33;1m%s\033[0m\n",
@@ -407,8 +431,11 @@ func formatEntryInfo(w io.Writer, e *scholar.Entry) {
 			fields = append(fields, f)
 		}
 	}
-	if value := e.File; value != "" {
-		fmt.Fprintf(w, "%s:\n  \033[31;4m%s\033[0m\n", "File", value)
+	if len(e.Files) > 0 {
+		fmt.Fprintf(w, "%s:\n", "Files")
+		for _, a := range e.Files {
+			fmt.Fprintf(w, "  \033[31;4m%s\033[0m\n", a)
+		}
 	}
 	sort.Strings(fields)
 	for _, field := range fields {
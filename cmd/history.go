@@ -0,0 +1,187 @@
+// Copyright © 2018 Eiji Onchi <eiji@onchi.me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cgxeiji/scholar/scholar"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history KEY [VERSION]",
+	Short: "Show the previous versions of an entry",
+	Long: `Scholar: a CLI Reference Manager
+
+List the previous versions of an entry. A new version is saved every time the
+entry is changed, in the .history directory of the entry.
+
+To show the changes made after a version run:
+
+	scholar history KEY VERSION
+
+To restore a version run:
+
+	scholar revert KEY --to VERSION
+
+Versions are numbered from the oldest. A revert saves the current version as a
+new version, so the list grows by one after each revert.
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			return history(args[0])
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errorf(exitUsage, "invalid version %q", args[1])
+		}
+		return historyDiff(args[0], n)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
+
+func history(key string) error {
+	lib, err := library()
+	if err != nil {
+		return err
+	}
+	revs, err := lib.History(key)
+	if err != nil {
+		return err
+	}
+
+	if len(revs) == 0 {
+		fmt.Println("No previous versions of", key)
+		return nil
+	}
+	for _, r := range revs {
+		fmt.Println(r)
+	}
+	fmt.Println(len(revs)+1, "current")
+
+	return nil
+}
+
+// historyDiff prints the changes between the version n of an entry and the
+// version after it.
+func historyDiff(key string, n int) error {
+	lib, err := library()
+	if err != nil {
+		return err
+	}
+	revs, err := lib.History(key)
+	if err != nil {
+		return err
+	}
+	if n < 1 || n > len(revs) {
+		return errorf(exitNotFound, "entry %s has no version %d", key, n)
+	}
+
+	from := revs[n-1]
+	to := scholar.Revision{
+		N:    n + 1,
+		Path: filepath.Join(lib.Dir(key), scholar.EntryFile),
+	}
+	label := fmt.Sprintf("%d current", to.N)
+	if n < len(revs) {
+		to = revs[n]
+		label = to.String()
+	}
+
+	a, err := ioutil.ReadFile(from.Path)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadFile(to.Path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("---", from)
+	fmt.Println("+++", label)
+	for _, line := range diffLines(lines(a), lines(b)) {
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+func lines(d []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(d), "\n"), "\n")
+}
+
+// diffLines returns the lines of a and b prefixed with "-" if they were
+// removed from a, "+" if they were added to b, or " " if they are in both.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var d []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			d = append(d, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			d = append(d, "-"+a[i])
+			i++
+		default:
+			d = append(d, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		d = append(d, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		d = append(d, "+"+b[j])
+	}
+
+	return d
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{"empty", nil, nil, nil},
+		{"equal", []string{"a", "b"}, []string{"a", "b"}, []string{" a", " b"}},
+		{"added", nil, []string{"a", "b"}, []string{"+a", "+b"}},
+		{"removed", []string{"a", "b"}, nil, []string{"-a", "-b"}},
+		{"changed", []string{"a", "b", "c"}, []string{"a", "x", "c"}, []string{" a", "-b", "+x", " c"}},
+		{"inserted", []string{"a", "c"}, []string{"a", "b", "c"}, []string{" a", "+b", " c"}},
+		{"deleted", []string{"a", "b", "c"}, []string{"a", "c"}, []string{" a", "-b", " c"}},
+		{"moved", []string{"a", "b", "c"}, []string{"b", "c", "a"}, []string{"-a", " b", " c", "+a"}},
+		{"trailing", []string{"a"}, []string{"a", "b", "c"}, []string{" a", "+b", "+c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -23,8 +23,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/cgxeiji/bib"
 	"github.com/cgxeiji/scholar/scholar"
 	"github.com/spf13/cobra"
 )
@@ -32,77 +33,107 @@ import (
 // importCmd represents the import command
 var importCmd = &cobra.Command{
 	Use:   "import",
-	Short: "Import a bibtex/biblatex file",
+	Short: "Import entries from a file",
 	Long: `Scholar: a CLI Reference Manager
 
-Import a bibtex/biblatex file into a library.
+Import the entries of a file into a library. The file is read as BibLaTeX
+by default. Other formats are selected with --format:
+
+	scholar import --format ris FILENAME
+
+The available formats are ` + strings.Join(scholar.ImportFormats(), ", ") + `.
+
+@string macros are expanded, and @preamble and @comment blocks are skipped.
+With --format bibtex, BibTeX fields such as journal, address, and year are
+converted to their BibLaTeX equivalents.
+
+Attached files with a relative path are copied from the directory of the
+imported file. EndNote links its files with paths relative to the PDF folder of
+the EndNote library, so save the EndNote XML file in that folder.
+
+The entries are validated before they are added. If any entry has invalid
+fields, the problems are printed and nothing is imported.
+
+The key of each entry is generated using the key template of the
+configuration file. To keep the keys of the imported file run:
+
+	scholar import --keep-keys FILENAME
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) > 0 {
-			importParse(args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errorf(exitUsage, "no file to import")
 		}
+		return importParse(args[0])
 	},
 }
 
+var importKeepKeys bool
+var importFormat string
+
 func init() {
 	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "biblatex", "Specify the import format (avail: "+strings.Join(scholar.ImportFormats(), ", ")+")")
+	importCmd.Flags().BoolVarP(&importKeepKeys, "keep-keys", "k", false, "keep the keys of the imported file")
 }
 
-func importParse(filename string) {
+func importParse(filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer file.Close()
 
-	entries, err := bib.Unmarshal(file)
+	entries, err := scholar.Import(file, importFormat)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	var es []*scholar.Entry
-
-	for _, entry := range entries {
-		e, err := scholar.NewEntry(entry["type"])
-		if err != nil {
-			if err == scholar.TypeNotFoundError {
-				e, err = scholar.NewEntry("misc")
-				if err != nil {
-					panic(err)
-				}
-			} else {
-				panic(err)
-			}
-		}
-		delete(entry, "type")
-		e.Key = entry["key"]
-		delete(entry, "key")
+	lib, err := library()
+	if err != nil {
+		return err
+	}
+	existing, err := libraryEntries(lib)
+	if err != nil {
+		return err
+	}
 
-		if file, ok := entry["file"]; ok {
-			e.File = file
-			delete(entry, "file")
-		}
+	var fs [][]scholar.Attachment
 
-		for req := range e.Required {
-			e.Required[req] = entry[req]
-			delete(entry, req)
+	valid := true
+	for _, e := range entries {
+		if !importKeepKeys {
+			e.Key = ""
 		}
 
-		for opt := range entry {
-			e.Optional[opt] = entry[opt]
-			delete(entry, opt)
+		// Files are attached after the entry is committed. Relative paths
+		// are relative to the imported file.
+		for i, a := range e.Files {
+			if !filepath.IsAbs(a.Path) {
+				e.Files[i].Path = filepath.Join(filepath.Dir(filename), a.Path)
+			}
 		}
+		fs = append(fs, e.Files)
+		e.Files = nil
 
-		es = append(es, e)
+		valid = checkEntry(e) && valid
+		warnDuplicates(e, existing)
+		existing = append(existing, e)
+	}
+	if !valid {
+		return errorf(exitInvalid, "invalid entries found in %s", filename)
 	}
 
 	// Only commit after all entries have been validated
-	for _, e := range es {
-		commit(e)
-		if e.File != "" {
-			attach(e, e.File)
+	for i, e := range entries {
+		if err := commit(e); err != nil {
+			return err
+		}
+		if err := attach(e, fs[i]...); err != nil {
+			return err
 		}
 	}
 
 	fmt.Println("Import from", filename, "successful!")
+	return nil
 }
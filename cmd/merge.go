@@ -0,0 +1,166 @@
+// Copyright © 2018 Eiji Onchi <eiji@onchi.me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cgxeiji/scholar/scholar"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge KEY1 KEY2",
+	Short: "Merge two entries",
+	Long: `Scholar: a CLI Reference Manager
+
+Merge two entries of the same work into the first one, and remove the second
+one. Empty fields take the value of the other entry, and the files attached to
+the second entry are moved to the first one. If the entries are of different
+types, the second entry is converted to the type of the first one.
+
+The second entry is saved in the history of the first one before it is
+removed, so it can be restored with "scholar history" and "scholar revert".
+
+If a field has different values in each entry, the value to keep is selected
+in interactive mode. Otherwise, the value is chosen with --keep:
+
+	first   keep the value of KEY1 (default)
+	second  keep the value of KEY2
+	longer  keep the longer value
+
+For example:
+
+	scholar merge -i --keep longer einstein1905 einstein1905a
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		policy, ok := mergePolicies[mergeKeep]
+		if !ok {
+			return errorf(exitUsage, "invalid value %q for --keep: must be first, second, or longer", mergeKeep)
+		}
+		if args[0] == args[1] {
+			return errorf(exitUsage, "cannot merge %s with itself", args[0])
+		}
+		return merge(args[0], args[1], policy)
+	},
+}
+
+var mergeKeep string
+
+var mergePolicies = map[string]scholar.MergePolicy{
+	"first":  scholar.KeepFirst,
+	"second": scholar.KeepSecond,
+	"longer": scholar.KeepLonger,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVarP(&mergeKeep, "keep", "k", "first", "value to keep on conflicts: first, second, or longer")
+}
+
+// merge merges the entry with key loser into the entry with key winner, and
+// removes loser from the library.
+func merge(winner, loser string, policy scholar.MergePolicy) error {
+	lib, err := library()
+	if err != nil {
+		return err
+	}
+	a, err := lib.Get(winner)
+	if err != nil {
+		return err
+	}
+	b, err := lib.Get(loser)
+	if err != nil {
+		return err
+	}
+
+	m, conflicts, err := scholar.Merge(a, b, policy)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range conflicts {
+		value := c.Value
+		if isInteractive() {
+			if value, err = selectValue(c); err != nil {
+				return err
+			}
+			m.Set(c.Field, value)
+		}
+		info.println("  .. conflict in", c.Field+":", "kept", fmt.Sprintf("%q", value))
+	}
+
+	// Files of the second entry are moved to the directory of the first one
+	for i := len(a.Files); i < len(m.Files); i++ {
+		f := &m.Files[i]
+		if filepath.IsAbs(f.Path) {
+			continue
+		}
+		info.println("  .. moving:", f.Path)
+		if f.Path, err = copyFile(filepath.Join(lib.Dir(loser), f.Path), lib.Dir(winner), f.Path); err != nil {
+			return err
+		}
+	}
+
+	// The history of loser is removed with it, so its last version is kept
+	// in the history of winner
+	if err := lib.SaveRevision(winner, loser); err != nil {
+		return err
+	}
+	if err := lib.Put(m); err != nil {
+		return err
+	}
+	if err := lib.Delete(loser); err != nil {
+		return err
+	}
+	checkEntry(m)
+
+	fmt.Println("Merged", loser, "into", winner)
+	fmt.Println("Removed", lib.Dir(loser), "(saved in the history of", winner+")")
+	return nil
+}
+
+// selectValue asks which value of a conflict to keep.
+func selectValue(c scholar.Conflict) (string, error) {
+	values := []string{c.First, c.Second}
+	cursor := 0
+	if c.Value == c.Second {
+		cursor = 1
+	}
+
+	prompt := promptui.Select{
+		Label:     fmt.Sprintf("Conflict in %q, keep", c.Field),
+		Items:     values,
+		CursorPos: cursor,
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", errAborted
+	}
+
+	return values[i], nil
+}
@@ -22,13 +22,8 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
-	"strings"
 
-	"github.com/cgxeiji/scholar/scholar"
 	"github.com/spf13/cobra"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // openCmd represents the open command
@@ -38,6 +33,7 @@ var openCmd = &cobra.Command{
 	Long: `Scholar: a CLI Reference Manager
 
 Open an entry's attached file with the default system's software.
+If there are multiple files attached, a selection menu appears.
 If no file is attached, it opens the entry's url.
 If no url is available, it opens the entry's DOI.
 
@@ -49,57 +45,46 @@ To specify which entry to open run:
 
 	scholar open SEARCH TERM
 
+To open the file attached with a role, such as "slides", run:
+
+	scholar open --role slides SEARCH TERM
+
 --------------------------------------------------------------------------------
-TODO: if there are multiple files attached, a selection menu appears.
 TODO: if there is no file attached, the entry's metadata file is opened.
 --------------------------------------------------------------------------------
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		if entry := queryEntry(args); entry != nil {
-			if entry.File != "" {
-				open(filepath.Join(libraryPath(), entry.GetKey(), entry.File))
-			} else if url, ok := entry.Optional["url"]; ok && url != "" {
-				open(url)
-			} else if url, ok := entry.Required["url"]; ok && url != "" {
-				open(url)
-			} else if doi, ok := entry.Optional["doi"]; ok && doi != "" {
-				open(fmt.Sprintf("https://dx.doi.org/%s", doi))
-			} else {
-				panic("no file, doi, or url associated with entry")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entry, err := queryEntry(args)
+		if err != nil {
+			return err
+		}
+		a, ok, err := selectAttachment(entry, openRole)
+		if err != nil {
+			return err
+		}
+		if ok {
+			path, err := attachmentPath(entry, a)
+			if err != nil {
+				return err
 			}
-		} else {
-			panic("entry not found")
+			return open(path)
+		} else if openRole != "" {
+			return errorf(exitNotFound, "no file with role '%s' attached to entry", openRole)
+		} else if url, ok := entry.Optional["url"]; ok && url != "" {
+			return open(url)
+		} else if url, ok := entry.Required["url"]; ok && url != "" {
+			return open(url)
+		} else if doi, ok := entry.Optional["doi"]; ok && doi != "" {
+			return open(fmt.Sprintf("https://dx.doi.org/%s", doi))
 		}
+		return errorf(exitNotFound, "no file, doi, or url associated with entry")
 	},
 }
 
+var openRole string
+
 func init() {
 	rootCmd.AddCommand(openCmd)
-}
-
-func entryFromKey(key string) *scholar.Entry {
-	dirs, err := ioutil.ReadDir(libraryPath())
-	if err != nil {
-		panic(err)
-	}
-
-	for _, dir := range dirs {
-		if dir.IsDir() && dir.Name() == strings.TrimSpace(key) {
-			d, err := ioutil.ReadFile(filepath.Join(libraryPath(), dir.Name(), "entry.yaml"))
-			if err != nil {
-				fmt.Println("Could not find data for:", dir.Name())
-				continue
-			}
-
-			var e scholar.Entry
-			err = yaml.Unmarshal(d, &e)
-			if err != nil {
-				panic(err)
-			}
-
-			return &e
-		}
-	}
 
-	return &scholar.Entry{}
+	openCmd.Flags().StringVarP(&openRole, "role", "r", "", "open the file attached with this role")
 }
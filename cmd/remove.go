@@ -22,11 +22,8 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // removeCmd represents the remove command
@@ -38,24 +35,30 @@ var removeCmd = &cobra.Command{
 Remove an entry from the library.  If interactive mode is disabled, the entry
 will be removed without confirmation.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		if entry := queryEntry(args); entry != nil {
-			path := filepath.Join(libraryPath(), entry.GetKey())
-			if viper.GetBool("GENERAL.interactive") != viper.GetBool("interactive") {
-				if askYesNo(fmt.Sprintf("Do you want to remove %s?", path)) {
-					if err := os.RemoveAll(path); err != nil {
-						panic(err)
-					}
-					fmt.Println("Removed", path)
-				}
-			} else {
-				path := filepath.Join(libraryPath(), entry.GetKey())
-				if err := os.RemoveAll(path); err != nil {
-					panic(err)
-				}
-				fmt.Println("Removed", path)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entry, err := queryEntry(args)
+		if err != nil {
+			return err
+		}
+		lib, err := library()
+		if err != nil {
+			return err
+		}
+		path := lib.Dir(entry.GetKey())
+		if isInteractive() {
+			ok, err := askYesNo(fmt.Sprintf("Do you want to remove %s?", path))
+			if err != nil {
+				return err
 			}
+			if !ok {
+				return nil
+			}
+		}
+		if err := lib.Delete(entry.GetKey()); err != nil {
+			return err
 		}
+		fmt.Println("Removed", path)
+		return nil
 	},
 }
 
@@ -0,0 +1,70 @@
+// Copyright © 2018 Eiji Onchi <eiji@onchi.me>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// revertCmd represents the revert command
+var revertCmd = &cobra.Command{
+	Use:   "revert KEY --to VERSION",
+	Short: "Restore a previous version of an entry",
+	Long: `Scholar: a CLI Reference Manager
+
+Restore a previous version of an entry. The current version is saved in the
+history first, as a new version numbered after the existing ones, so a revert
+can be undone by reverting to that version. Each revert adds a version, so run
+"scholar history KEY" again before choosing the next --to VERSION.
+
+To list the versions of an entry run:
+
+	scholar history KEY
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("to") {
+			return errorf(exitUsage, "the version to restore is not set, use --to VERSION")
+		}
+		lib, err := library()
+		if err != nil {
+			return err
+		}
+		e, err := lib.Revert(args[0], revertTo)
+		if err != nil {
+			return err
+		}
+		checkEntry(e)
+
+		fmt.Println("Reverted", args[0], "to version", revertTo)
+		return nil
+	},
+}
+
+var revertTo int
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+
+	revertCmd.Flags().IntVarP(&revertTo, "to", "t", 0, "version to restore (see scholar history)")
+}
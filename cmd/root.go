@@ -42,39 +42,74 @@ var rootCmd = &cobra.Command{
 	Long: `Scholar: a CLI Reference Manager
 
 Scholar is a CLI reference manager that keeps track of
-your documents metadata using YAML files with biblatex format.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(os.Args[1:]) == 0 {
-			cmd.Help()
-		}
-		return
+your documents metadata using YAML files with biblatex format.
+
+Interactive prompts are skipped when stdin or stdout is not a terminal.
+
+Exit codes:
+  0  success
+  1  unexpected error
+  2  invalid command, flag, or argument
+  3  no entry, file, type, format, or style found
+  4  the search matched more than one entry
+  5  library not found or not configured
+  6  metadata could not be fetched from the network
+  7  invalid entry or input file
+  8  a file could not be read or written
+  9  canceled by the user
+
+With --json, errors are printed to stderr as:
+  {"error":{"code":3,"kind":"not_found","message":"no entries found"}}`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		started = true
+		return initConfig()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// It exits with the exit code of the error returned by the command.
 func Execute() {
+	os.Exit(execute())
+}
+
+// started is set once the flags and arguments of the command are parsed.
+// Errors returned before that are usage errors.
+var started bool
+
+func execute() (code int) {
 	defer func() {
 		if r := recover(); r != nil {
-			info.error(r)
-			os.Exit(1)
+			code = report(fmt.Errorf("%v", r))
 		}
 	}()
+
 	if err := rootCmd.Execute(); err != nil {
-		panic(err)
+		if !started {
+			err = &cliError{code: exitUsage, err: err}
+		}
+		return report(err)
 	}
+	return exitOK
 }
 
+var jsonErrors bool
+
 func init() {
-	cobra.OnInitialize(initConfig)
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
 
 	rootCmd.PersistentFlags().StringVarP(&currentLibrary, "library", "l", "", "specify the library")
 	rootCmd.PersistentFlags().BoolP("interactive", "i", false, "toggle interactive mode (enabled by default)")
+	rootCmd.PersistentFlags().BoolVar(&jsonErrors, "json", false, "print errors to stderr as JSON")
 	viper.BindPFlag("interactive", rootCmd.PersistentFlags().Lookup("interactive"))
 }
 
 // initConfig reads in config file and ENV variables if set.
-func initConfig() {
+func initConfig() error {
 	if confFile != "" && confFile != "which" {
 		// Use config file from the flag.
 		viper.SetConfigFile(confFile)
@@ -91,6 +126,7 @@ func initConfig() {
 	// Set default values
 	viper.SetDefault("GENERAL.interactive", true)
 	viper.SetDefault("GENERAL.editor", "vi")
+	viper.SetDefault("GENERAL.keytemplate", scholar.DefaultKeyTemplate)
 
 	// Load the configuration file. If not found, auto-generate one.
 	if err := viper.ReadInConfig(); err != nil {
@@ -100,23 +136,23 @@ func initConfig() {
 		path, _ := homedir.Dir()
 		path = filepath.Join(path, ".config", "scholar")
 		if err := os.MkdirAll(path, os.ModePerm); err != nil {
-			panic(err)
+			return err
 		}
 
 		path = filepath.Join(path, "config.yaml")
 		switch runtime.GOOS {
 		case "windows":
 			if err := ioutil.WriteFile(path, configTemplateWin, 0644); err != nil {
-				panic(err)
+				return err
 			}
 		default:
 			if err := ioutil.WriteFile(path, configTemplate, 0644); err != nil {
-				panic(err)
+				return err
 			}
 		}
 
 		if err := viper.ReadInConfig(); err != nil {
-			panic(err)
+			return err
 		}
 	}
 
@@ -127,7 +163,7 @@ func initConfig() {
 	for k, v := range viper.GetStringMapString("LIBRARIES") {
 		vex, err := homedir.Expand(v)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		viper.Set("LIBRARIES."+k, vex)
 	}
@@ -151,16 +187,16 @@ func initConfig() {
 		path, _ := homedir.Dir()
 		path = filepath.Join(path, ".config", "scholar")
 		if err := os.MkdirAll(path, os.ModePerm); err != nil {
-			panic(err)
+			return err
 		}
 
 		path = filepath.Join(path, "types.yaml")
 		if err := ioutil.WriteFile(path, typesTemplate, 0644); err != nil {
-			panic(err)
+			return err
 		}
 
 		if err := et.ReadInConfig(); err != nil {
-			panic(err)
+			return err
 		}
 	}
 
@@ -170,10 +206,16 @@ func initConfig() {
 
 	err := scholar.LoadTypes(et.ConfigFileUsed())
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	scholar.KeyFormat, err = scholar.ParseKeyTemplate(viper.GetString("GENERAL.keytemplate"))
+	if err != nil {
+		return err
 	}
 
 	if !isInteractive() {
 		info.setLevel(0)
 	}
+	return nil
 }
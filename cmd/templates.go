@@ -38,6 +38,10 @@ GENERAL:
   interactive: true
   # Set the email for polite use of CrossRef
   mailto: mail@example.com
+  # Set the template used to generate the key of new entries.
+  # For example, "{auth.lower}{year}{shorttitle:1}" generates keys
+  # like einstein1905electrodynamics.
+  keytemplate: "{auth.lower}{year}"
 
 # Path locations for the libraries.
 # You can add as many libraries as you want.
@@ -66,6 +70,10 @@ GENERAL:
   interactive: true
   # Set the email for polite use of CrossRef
   mailto: mail@example.com
+  # Set the template used to generate the key of new entries.
+  # For example, "{auth.lower}{year}{shorttitle:1}" generates keys
+  # like einstein1905electrodynamics.
+  keytemplate: "{auth.lower}{year}"
 
 # Path locations for the libraries.
 # You can add as many libraries as you want.
@@ -82,6 +90,22 @@ var typesTemplate = []byte(`# Entry Types Template
 # Feel free to modify it as you like.
 # If there is any problem with the configuration, delete this file
 # and Scholar will auto-generate another file.
+#
+# An entry type can inherit the fields of another entry type with
+# "extends", and map alternative field names to its fields with
+# "aliases".
+#
+# A field can be defined only by its description, or with its datatype
+# (literal, namelist, list, date, range, uri, verbatim, integer, key),
+# default value, and validation pattern:
+#
+#   pages:
+#     desc: Range of pages.
+#     type: range
+#     default: ""
+#     pattern: "^[0-9]+(--[0-9]+)?$"
+#
+# If the datatype is not defined, it is guessed from the field name.
 
 article:
   desc: An article in a journal, magazine, newspaper, or other periodical which forms a self-contained unit with its own title.
@@ -100,6 +124,8 @@ article:
     doi: DOI code of the article.
     url: URL of the article.
     urldate: Access date in YYYY-MM-DD format.
+  aliases:
+    journal: journaltitle
 
 book:
   desc: A single-volume book with one or more authors where the authors share credit for the work as a whole.
@@ -120,48 +146,23 @@ book:
     doi: DOI code of the book.
     url: URL of the book.
     urldate: Access date in YYYY-MM-DD format.
+  aliases:
+    address: location
 
 mvbook:
   desc: A multi-volume @book.
-  req:
-    author: Author(s) of the book.
-    title: Title of the book.
-    date: YYYY-MM-DD format.
-  opt:
-    editor: Editor(s) of the book.
-    publisher: Publisher of the book.
-    location: Location of the publishing company.
-    language: Language of the book.
-    series: Series of the book.
-    volume: Volume of the book.
-    number: Number of the book.
-    pages: Number of pages is the book.
-    isbn: ISBN number of the book.
-    doi: DOI code of the book.
-    url: URL of the book.
-    urldate: Access date in YYYY-MM-DD format.
+  extends: book
 
 inbook:
   desc: A part of a book which forms a self-contained unit with its own title.
+  extends: book
   req:
     author: Author(s) of the book section.
     title: Title of the book section.
     booktitle: Title of the book.
-    date: YYYY-MM-DD format.
   opt:
     bookauthor: Author(s) of the book.
-    editor: Editor(s) of the book.
-    publisher: Publisher of the book.
-    location: Location of the publisher.
-    language: Language of the book.
-    series: Series of the book.
-    volume: Volume of the book.
-    number: Number of the book.
     pages: Range of pages in the book.
-    isbn: ISBN number of the book.
-    doi: DOI code of the book.
-    url: URL of the book.
-    urldate: Access date in YYYY-MM-DD format.
 
 collection:
   desc: A single-volume collection with multiple, self-contained contributions by distinct authors which have their own title. The work as a whole has no overall author but it will usually have an editor.
@@ -219,6 +220,8 @@ report:
     doi: DOI code of the report.
     url: URL of the report.
     urldate: Access date in YYYY-MM-DD format.
+  aliases:
+    school: institution
 
 thesis:
   desc: A thesis written for an educational institution to satisfy the requirements for a degree.
@@ -238,6 +241,8 @@ thesis:
     doi: DOI code of the thesis.
     url: URL of the thesis.
     urldate: Access date in YYYY-MM-DD format.
+  aliases:
+    school: institution
 
 manual:
   desc: Technical or other documentation, not necessarily in printed form.
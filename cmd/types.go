@@ -44,16 +44,17 @@ where NUM can be:
   1: Print required fields
   2: Print required and optional fields
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var level int
 		if len(args) > 0 {
 			var err error
 			level, err = strconv.Atoi(args[0])
 			if err != nil {
-				panic(err)
+				return errorf(exitUsage, "invalid level %q: must be 0, 1, or 2", args[0])
 			}
 		}
 		scholar.TypesInfo(level)
+		return nil
 	},
 }
 
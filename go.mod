@@ -3,7 +3,6 @@ module github.com/cgxeiji/scholar
 go 1.15
 
 require (
-	github.com/cgxeiji/bib v0.0.0-20181101073836-b38b7ac92115
 	github.com/cgxeiji/crossref v0.1.0
 	github.com/cgxeiji/scholar/scholar v0.0.0-20201026105106-0d42301c4635
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
@@ -27,3 +26,5 @@ require (
 	gopkg.in/ini.v1 v1.62.0 // indirect
 	gopkg.in/yaml.v2 v2.3.0
 )
+
+replace github.com/cgxeiji/scholar/scholar => ./scholar
@@ -0,0 +1,117 @@
+package scholar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Attachment is a file attached to an entry. The path is relative to the
+// directory of the entry, unless it is an absolute path. The role tells what
+// the file is, such as "preprint", "published", "supplementary", or
+// "slides".
+type Attachment struct {
+	Path        string `yaml:"path"`
+	Role        string `yaml:"role,omitempty"`
+	Description string `yaml:"desc,omitempty"`
+}
+
+// String implements the Stringer interface.
+func (a Attachment) String() string {
+	s := a.Path
+	if a.Role != "" {
+		s = fmt.Sprintf("[%s] %s", a.Role, s)
+	}
+	if a.Description != "" {
+		s = fmt.Sprintf("%s (%s)", s, a.Description)
+	}
+	return s
+}
+
+// roleRx matches the words that can be used as roles.
+var roleRx = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// ParseAttachment parses an attachment written as [ROLE=]PATH, such as
+// "slides=talk.pdf" or "paper.pdf". The text before the first '=' is only
+// a role if it is a word, such as "slides" or "supplementary-2", so paths like
+// "./a=b.pdf" or "v1.2=final.pdf" are kept as they are.
+func ParseAttachment(s string) Attachment {
+	if i := strings.IndexRune(s, '='); i > 0 && roleRx.MatchString(s[:i]) {
+		return Attachment{Path: s[i+1:], Role: s[:i]}
+	}
+	return Attachment{Path: s}
+}
+
+// ParseFiles parses the file field of a BibTeX entry. Files are separated by
+// ';' and written either as PATH or in the DESCRIPTION:PATH:ROLE format used by
+// JabRef.
+func ParseFiles(s string) []Attachment {
+	var files []Attachment
+	for _, f := range strings.Split(s, ";") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		parts := strings.Split(f, ":")
+		if len(parts) < 3 {
+			files = append(files, Attachment{Path: f})
+			continue
+		}
+		files = append(files, Attachment{
+			Path:        strings.Join(parts[1:len(parts)-1], ":"),
+			Role:        parts[len(parts)-1],
+			Description: parts[0],
+		})
+	}
+	return files
+}
+
+// Attach attaches files to the entry. Attachments with the same path as an
+// existing attachment replace it.
+func (e *Entry) Attach(files ...Attachment) {
+	for _, a := range files {
+		replaced := false
+		for i := range e.Files {
+			if e.Files[i].Path == a.Path {
+				e.Files[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			e.Files = append(e.Files, a)
+		}
+	}
+}
+
+// Attachment returns the first file attached to the entry with the given
+// role. If role is empty, the first attached file is returned.
+func (e *Entry) Attachment(role string) (Attachment, bool) {
+	for _, a := range e.Files {
+		if role == "" || a.Role == role {
+			return a, true
+		}
+	}
+	return Attachment{}, false
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface. Entries saved with
+// the single "file" field of older versions are migrated to the list of
+// attached files.
+func (e *Entry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type entry Entry
+	var aux struct {
+		entry `yaml:",inline"`
+		File  string `yaml:"file"`
+	}
+	if err := unmarshal(&aux); err != nil {
+		return err
+	}
+
+	*e = Entry(aux.entry)
+	if aux.File != "" {
+		e.Files = append([]Attachment{{Path: aux.File}}, e.Files...)
+	}
+
+	return nil
+}
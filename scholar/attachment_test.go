@@ -0,0 +1,121 @@
+package scholar
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestEntry_UnmarshalYAML(t *testing.T) {
+	legacy := []byte(`type: article
+key: last2006
+req:
+  title: The Title
+file: last2006_the_title.pdf
+files:
+- path: slides.pdf
+  role: slides
+  desc: Conference talk
+`)
+
+	var e Entry
+	if err := yaml.Unmarshal(legacy, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Key != "last2006" || e.Required["title"] != "The Title" {
+		t.Errorf("UnmarshalYAML() did not read the entry: %+v", e)
+	}
+
+	want := []Attachment{
+		{Path: "last2006_the_title.pdf"},
+		{Path: "slides.pdf", Role: "slides", Description: "Conference talk"},
+	}
+	if len(e.Files) != len(want) {
+		t.Fatalf("Files = %v, want %v", e.Files, want)
+	}
+	for i := range want {
+		if e.Files[i] != want[i] {
+			t.Errorf("Files[%d] = %v, want %v", i, e.Files[i], want[i])
+		}
+	}
+
+	d, err := yaml.Marshal(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(d, &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["file"]; ok {
+		t.Errorf("MarshalYAML() kept the legacy file field:\n%s", d)
+	}
+}
+
+func TestParseAttachment(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Attachment
+	}{
+		{"paper.pdf", Attachment{Path: "paper.pdf"}},
+		{"slides=talk.pdf", Attachment{Path: "talk.pdf", Role: "slides"}},
+		{"supplementary-2=data.zip", Attachment{Path: "data.zip", Role: "supplementary-2"}},
+		{"./a=b.pdf", Attachment{Path: "./a=b.pdf"}},
+		{"v1.2=final.pdf", Attachment{Path: "v1.2=final.pdf"}},
+		{"=paper.pdf", Attachment{Path: "=paper.pdf"}},
+		{"dir/x=y.pdf", Attachment{Path: "dir/x=y.pdf"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseAttachment(tt.in); got != tt.want {
+			t.Errorf("ParseAttachment(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEntry_Attach(t *testing.T) {
+	var e Entry
+	e.Attach(ParseAttachment("paper.pdf"), ParseAttachment("slides=talk.pdf"))
+	e.Attach(Attachment{Path: "paper.pdf", Role: "published"})
+
+	if len(e.Files) != 2 {
+		t.Fatalf("Files = %v, want 2 files", e.Files)
+	}
+
+	a, ok := e.Attachment("published")
+	if !ok || a.Path != "paper.pdf" {
+		t.Errorf("Attachment(%q) = %v, want paper.pdf", "published", a)
+	}
+	a, ok = e.Attachment("slides")
+	if !ok || a.Path != "talk.pdf" {
+		t.Errorf("Attachment(%q) = %v, want talk.pdf", "slides", a)
+	}
+	if _, ok := e.Attachment("data"); ok {
+		t.Errorf("Attachment(%q) found a file", "data")
+	}
+
+	want := ":paper.pdf:published;:talk.pdf:slides"
+	if got := bibFiles(e.Files); got != want {
+		t.Errorf("bibFiles() = %q, want %q", got, want)
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	files := []Attachment{
+		{Path: "paper.pdf", Role: "published"},
+		{Path: "C:/papers/talk.pdf", Role: "slides", Description: "Conference talk"},
+	}
+	got := ParseFiles(bibFiles(files))
+	if len(got) != len(files) {
+		t.Fatalf("ParseFiles() = %v, want %v", got, files)
+	}
+	for i := range files {
+		if got[i] != files[i] {
+			t.Errorf("ParseFiles()[%d] = %#v, want %#v", i, got[i], files[i])
+		}
+	}
+
+	if got := ParseFiles("paper.pdf"); len(got) != 1 || got[0].Path != "paper.pdf" {
+		t.Errorf("ParseFiles(%q) = %v, want [paper.pdf]", "paper.pdf", got)
+	}
+}
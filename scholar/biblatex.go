@@ -1,55 +1,91 @@
 package scholar
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 )
 
-type exBiblatex struct{}
+type exBiblatex struct {
+	// ascii writes Unicode letters as LaTeX commands.
+	ascii bool
+}
+
+func (ex *exBiblatex) withASCII() Exporter {
+	return &exBiblatex{ascii: true}
+}
 
-func (ex *exBiblatex) export(e *Entry) string {
-	bib := new(strings.Builder)
+// Begin implements the Exporter interface.
+func (ex *exBiblatex) Begin(w io.Writer) error {
+	return nil
+}
+
+// Entry implements the Exporter interface. Entries are separated by a blank
+// line.
+func (ex *exBiblatex) Entry(w io.Writer, e *Entry, i int) error {
+	bib := bufio.NewWriter(w)
+	if i > 0 {
+		bib.WriteString("\n")
+	}
 
 	// @type{key,
 	fmt.Fprintf(bib, "@%s{%s", e.Type, e.GetKey())
 
 	// field = {value},
-	fields := make([]string, len(e.Required))
-	i := 0
+	fields := make([]string, 0, len(e.Required))
 	for field := range e.Required {
-		fields[i] = field
-		i++
+		fields = append(fields, field)
 	}
 	sort.Strings(fields)
 	for _, field := range fields {
 		if value := e.Required[field]; value != "" {
+			value = e.texField(field, e.formatField(field, value), ex.ascii)
 			fmt.Fprintf(bib, ",\n  %s = {%s}", field, value)
 		}
 	}
 
-	fields = make([]string, len(e.Optional))
-	i = 0
+	fields = make([]string, 0, len(e.Optional))
 	for field := range e.Optional {
-		fields[i] = field
-		i++
+		fields = append(fields, field)
 	}
 	sort.Strings(fields)
 	for _, field := range fields {
 		if value := e.Optional[field]; value != "" && field != "abstract" {
+			value = e.texField(field, e.formatField(field, value), ex.ascii)
 			fmt.Fprintf(bib, ",\n  %s = {%s}", field, value)
 		}
 	}
 	if value, ok := e.Optional["abstract"]; ok {
-		fmt.Fprintf(bib, ",\n  %s = {%s}", "abstract", value)
+		fmt.Fprintf(bib, ",\n  %s = {%s}", "abstract", e.texField("abstract", value, ex.ascii))
 	}
-	if file := e.File; file != "" {
-		fmt.Fprintf(bib, ",\n  %s = {%s}", "file", file)
+	if len(e.Files) > 0 {
+		fmt.Fprintf(bib, ",\n  %s = {%s}", "file", bibFiles(e.Files))
 	}
 
-	bib.WriteString("\n}")
+	bib.WriteString("\n}\n")
 
-	return bib.String()
+	return bib.Flush()
+}
+
+// End implements the Exporter interface.
+func (ex *exBiblatex) End(w io.Writer) error {
+	return nil
+}
+
+// bibFiles returns the attached files in the format used by JabRef, where
+// each file is written as DESCRIPTION:PATH:ROLE and separated by ';'. A single
+// file without description nor role is written only by its path.
+func bibFiles(files []Attachment) string {
+	if len(files) == 1 && files[0].Role == "" && files[0].Description == "" {
+		return files[0].Path
+	}
+	s := make([]string, len(files))
+	for i, a := range files {
+		s[i] = fmt.Sprintf("%s:%s:%s", a.Description, a.Path, a.Role)
+	}
+	return strings.Join(s, ";")
 }
 
 var biblatex = &exBiblatex{}
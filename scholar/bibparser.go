@@ -0,0 +1,405 @@
+package scholar
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type imBibtex struct {
+	dict map[string]string
+}
+
+func (im *imBibtex) parse(v string) string {
+	if s, ok := im.dict[v]; ok {
+		return s
+	}
+	return v
+}
+
+// read parses a BibTeX or BibLaTeX file. Entries of a type that is not loaded
+// are read as "misc" entries. The year and month fields are converted to a
+// date if the entry has no date.
+func (im *imBibtex) read(r io.Reader) ([]*Entry, error) {
+	d, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, getError("Import", ErrIO, err)
+	}
+
+	p := &bibParser{s: string(d), line: 1, macros: make(map[string]string)}
+	for m, n := range monthText {
+		p.macros[n] = m
+	}
+	records, err := p.records()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(records))
+	for _, rec := range records {
+		e, err := NewEntry(im.parse(rec.typ))
+		if IsError(ErrTypeNotFound, err) {
+			e, err = NewEntry("misc")
+		}
+		if err != nil {
+			return nil, getError("Import", errNotDefined, err).
+				info(fmt.Sprintf("line %d: cannot read @%s{%s}", rec.line, rec.typ, rec.key))
+		}
+		e.Key = rec.key
+
+		var year, month string
+		for _, f := range rec.fields {
+			switch f.name {
+			case "file":
+				e.Attach(ParseFiles(f.value)...)
+			case "year":
+				year = f.value
+			case "month":
+				month = f.value
+			default:
+				name, value := im.parse(f.name), f.value
+				if texData(e.Datatype(name)) {
+					value = decodeLaTeX(value)
+				}
+				e.Set(name, value)
+			}
+		}
+		if date := e.field("date"); date == "" && year != "" {
+			e.Set("date", bibDate(year, month))
+		} else {
+			if year != "" {
+				e.Set("year", year)
+			}
+			if month != "" {
+				e.Set("month", month)
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// bibDate returns the date in YYYY[-MM] format from the year and month fields.
+func bibDate(year, month string) string {
+	m, err := strconv.Atoi(month)
+	if err != nil {
+		for n, t := range monthText {
+			if len(month) >= 3 && strings.HasPrefix(strings.ToLower(month), t) {
+				m, _ = strconv.Atoi(n)
+			}
+		}
+	}
+	if m < 1 || m > 12 {
+		return year
+	}
+	return fmt.Sprintf("%s-%02d", year, m)
+}
+
+type bibField struct {
+	name  string
+	value string
+}
+
+type bibRecord struct {
+	typ    string
+	key    string
+	line   int
+	fields []bibField
+}
+
+// bibParser reads the records of a BibTeX file. It expands @string macros and
+// '#' concatenations, and skips @comment and @preamble blocks, as well as any
+// text outside of records.
+type bibParser struct {
+	s      string
+	pos    int
+	line   int
+	macros map[string]string
+}
+
+func (p *bibParser) errorf(format string, a ...interface{}) error {
+	return getError("Import", ErrParse, nil).
+		info(fmt.Sprintf("line %d: %s", p.line, fmt.Sprintf(format, a...)))
+}
+
+func (p *bibParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *bibParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *bibParser) next() byte {
+	c := p.peek()
+	if c == '\n' {
+		p.line++
+	}
+	p.pos++
+	return c
+}
+
+func (p *bibParser) skipSpace() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) != -1 {
+		p.next()
+	}
+}
+
+func (p *bibParser) expect(c byte) error {
+	p.skipSpace()
+	if p.eof() {
+		return p.errorf("expected %q, found end of file", c)
+	}
+	if p.peek() != c {
+		return p.errorf("expected %q, found %q", c, p.peek())
+	}
+	p.next()
+	return nil
+}
+
+// ident reads a type, key, field, or macro name.
+func (p *bibParser) ident() string {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\r\n{}(),=\"#%", p.peek()) == -1 {
+		p.next()
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *bibParser) records() ([]bibRecord, error) {
+	var records []bibRecord
+
+	for {
+		i := strings.IndexByte(p.s[p.pos:], '@')
+		if i == -1 {
+			return records, nil
+		}
+		p.line += strings.Count(p.s[p.pos:p.pos+i], "\n")
+		p.pos += i + 1
+
+		line := p.line
+		p.skipSpace()
+		typ := strings.ToLower(p.ident())
+		p.skipSpace()
+		var closing byte
+		switch p.peek() {
+		case '{':
+			closing = '}'
+		case '(':
+			closing = ')'
+		default:
+			// not a record, such as an email address in a comment
+			continue
+		}
+
+		if typ == "comment" {
+			if closing == ')' {
+				i := strings.IndexByte(p.s[p.pos:], ')')
+				if i == -1 {
+					return records, nil
+				}
+				p.line += strings.Count(p.s[p.pos:p.pos+i], "\n")
+				p.pos += i + 1
+			} else if _, err := p.braced(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.next()
+
+		switch typ {
+		case "preamble":
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+		case "string":
+			p.skipSpace()
+			name := strings.ToLower(p.ident())
+			if name == "" {
+				return nil, p.errorf("expected a string name")
+			}
+			if err := p.expect('='); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			p.macros[name] = v
+		default:
+			rec, err := p.record(typ, closing)
+			if err != nil {
+				return nil, err
+			}
+			rec.line = line
+			records = append(records, rec)
+			continue
+		}
+
+		if err := p.expect(closing); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// record reads the key and fields of an entry, up to the closing delimiter.
+func (p *bibParser) record(typ string, closing byte) (bibRecord, error) {
+	rec := bibRecord{typ: typ}
+
+	p.skipSpace()
+	start := p.pos
+	for !p.eof() && p.peek() != ',' && p.peek() != closing {
+		if c := p.peek(); c == '\n' || c == '=' {
+			return rec, p.errorf("expected ',' after the key of @%s", typ)
+		}
+		p.next()
+	}
+	rec.key = strings.TrimSpace(p.s[start:p.pos])
+
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case 0:
+			return rec, p.errorf("@%s{%s is not closed", typ, rec.key)
+		case closing:
+			p.next()
+			return rec, nil
+		case ',':
+			p.next()
+			continue
+		}
+
+		name := strings.ToLower(p.ident())
+		if name == "" {
+			return rec, p.errorf("expected a field name in @%s{%s, found %q", typ, rec.key, p.peek())
+		}
+		if err := p.expect('='); err != nil {
+			return rec, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return rec, err
+		}
+		rec.fields = append(rec.fields, bibField{name: name, value: v})
+
+		p.skipSpace()
+		switch p.peek() {
+		case 0:
+			return rec, p.errorf("@%s{%s is not closed", typ, rec.key)
+		case ',', closing:
+		default:
+			return rec, p.errorf("expected ',' or %q after field %q", closing, name)
+		}
+	}
+}
+
+// value reads a field value made of braced texts, quoted texts, numbers, and
+// macros, concatenated by '#'. Runs of white space are collapsed.
+func (p *bibParser) value() (string, error) {
+	var b strings.Builder
+	for {
+		p.skipSpace()
+		var v string
+		var err error
+		switch c := p.peek(); {
+		case c == 0:
+			return "", p.errorf("expected a value, found end of file")
+		case c == '{':
+			v, err = p.braced()
+		case c == '"':
+			v, err = p.quoted()
+		default:
+			name := p.ident()
+			if name == "" {
+				return "", p.errorf("expected a value, found %q", c)
+			}
+			if _, e := strconv.Atoi(name); e == nil {
+				v = name
+				break
+			}
+			m, ok := p.macros[strings.ToLower(name)]
+			if !ok {
+				return "", p.errorf("undefined string %q", name)
+			}
+			v = m
+		}
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+
+		p.skipSpace()
+		if p.peek() != '#' {
+			break
+		}
+		p.next()
+	}
+
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+// braced reads a text enclosed in braces. Nested braces are kept.
+func (p *bibParser) braced() (string, error) {
+	line := p.line
+	p.next()
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		switch p.next() {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return p.s[start : p.pos-1], nil
+			}
+			depth--
+		}
+	}
+	p.line = line
+	return "", p.errorf("unbalanced braces")
+}
+
+// quoted reads a text enclosed in double quotes. Quotes inside braces do not
+// end the text.
+func (p *bibParser) quoted() (string, error) {
+	line := p.line
+	p.next()
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		switch p.next() {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return "", p.errorf("unbalanced braces")
+			}
+			depth--
+		case '"':
+			if depth == 0 {
+				return p.s[start : p.pos-1], nil
+			}
+		}
+	}
+	p.line = line
+	return "", p.errorf("unterminated quoted text")
+}
+
+var biblatexParser = &imBibtex{}
+
+var bibtexParser = &imBibtex{
+	dict: map[string]string{
+		"techreport": "report",
+		"address":    "location",
+		"journal":    "journaltitle",
+		"school":     "institution",
+		"language":   "langid",
+	},
+}
@@ -0,0 +1,116 @@
+package scholar
+
+import (
+	"strings"
+	"testing"
+)
+
+const mockBib = `This text is ignored, and so is mail@example.com.
+
+@preamble{"\newcommand{\noopsort}[1]{}" # "\newcommand{\x}{x}"}
+@string{jphys = "Journal of " # {Physics}}
+@comment{@article{ignored, title = {Ignored}}}
+
+@Article{einstein1905,
+  Author    = {Einstein, Albert},
+  title     = "Zur {E}lektrodynamik bewegter {"}K{\"o}rper{"}",
+  journal   = jphys,
+  year      = 1905,
+  month     = jun,
+  pages     = {891--921},
+}
+
+@book(knuth1984,
+  author = {Donald E. Knuth},
+  title  = {The {\TeX}book},
+  date   = {1984},
+  year   = {1986},
+  file   = {:knuth.pdf:published;Notes:notes.txt:notes}
+)
+
+@unknown{other,
+  title = {Some
+           other    {work}},
+  author = {Nobody}
+}
+`
+
+func TestImport(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Import(strings.NewReader(mockBib), "bibtex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Import() returned %d entries, want 3", len(entries))
+	}
+
+	tests := []struct {
+		entry int
+		field string
+		want  string
+	}{
+		{0, "author", "Einstein, Albert"},
+		{0, "title", `Zur {E}lektrodynamik bewegter {"}Körper{"}`},
+		{0, "journaltitle", "Journal of Physics"},
+		{0, "date", "1905-06"},
+		{0, "pages", "891--921"},
+		{1, "author", "Donald E. Knuth"},
+		{1, "title", `The {\TeX}book`},
+		{1, "date", "1984"},
+		{1, "year", "1986"},
+		{2, "title", "Some other {work}"},
+	}
+	for _, tt := range tests {
+		t.Run(entries[tt.entry].Key+"/"+tt.field, func(t *testing.T) {
+			if got := entries[tt.entry].field(tt.field); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+
+	if e := entries[0]; e.Type != "article" || e.Key != "einstein1905" {
+		t.Errorf("got @%s{%s}, want @article{einstein1905}", e.Type, e.Key)
+	}
+	if e := entries[2]; e.Type != "misc" || e.Key != "other" {
+		t.Errorf("got @%s{%s}, want @misc{other}", e.Type, e.Key)
+	}
+
+	files := entries[1].Files
+	if len(files) != 2 || files[0].Path != "knuth.pdf" || files[1].Role != "notes" {
+		t.Errorf("Files = %v, want [knuth.pdf notes.txt]", files)
+	}
+}
+
+func TestImport_Error(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		line string
+	}{
+		{"unbalanced", "@article{a,\n  title = {The {Title}\n", "line 2:"},
+		{"unterminated", "\n\n@article{a,\n  title = \"The Title}\n", "line 4:"},
+		{"undefined string", "@article{a,\n  journal = jphys\n}", "line 2:"},
+		{"missing comma", "@article{a,\n  title = {A}\n  date = {2006}\n}", "line 3:"},
+		{"not closed", "@article{a,\n  title = {A},\n", "line 3:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(strings.NewReader(tt.in), "biblatex")
+			if !IsError(ErrParse, err) {
+				t.Fatalf("Import() error = %v, want ErrParse", err)
+			}
+			if !strings.Contains(err.Error(), tt.line) {
+				t.Errorf("Import() error = %v, want %q", err, tt.line)
+			}
+		})
+	}
+}
@@ -1,13 +1,22 @@
 package scholar
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 )
 
 type exBibtex struct {
 	dict map[string]string
+	// ascii writes Unicode letters as LaTeX commands, for BibTeX versions
+	// that only read ASCII files.
+	ascii bool
+}
+
+func (ex *exBibtex) withASCII() Exporter {
+	return &exBibtex{dict: ex.dict, ascii: true}
 }
 
 func (ex *exBibtex) parse(v string) string {
@@ -17,8 +26,18 @@ func (ex *exBibtex) parse(v string) string {
 	return v
 }
 
-func (ex *exBibtex) export(e *Entry) string {
-	bib := new(strings.Builder)
+// Begin implements the Exporter interface.
+func (ex *exBibtex) Begin(w io.Writer) error {
+	return nil
+}
+
+// Entry implements the Exporter interface. Entries are separated by a blank
+// line.
+func (ex *exBibtex) Entry(w io.Writer, e *Entry, i int) error {
+	bib := bufio.NewWriter(w)
+	if i > 0 {
+		bib.WriteString("\n")
+	}
 
 	// @type{key,
 	fmt.Fprintf(bib, "@%s{%s", ex.parse(e.Type), e.GetKey())
@@ -26,15 +45,10 @@ func (ex *exBibtex) export(e *Entry) string {
 	urltmp := ""
 
 	// field = {value},
-	fields := make([]string, len(e.Required))
-	i := 0
-	for field := range e.Required {
-		fields[i] = field
-		i++
-	}
-	sort.Strings(fields)
-	for _, field := range fields {
-		if value := e.Required[field]; value != "" {
+	write := func(field, value string) {
+		value = e.texField(field, e.formatField(field, value), ex.ascii)
+		switch e.Datatype(field) {
+		case DataDate:
 			switch field {
 			case "date":
 				date := strings.Split(value, "-")
@@ -42,51 +56,57 @@ func (ex *exBibtex) export(e *Entry) string {
 				if len(date) > 1 {
 					fmt.Fprintf(bib, ",\n  %s = {%s}", "month", monthText[date[1]])
 				}
-			case "url":
-				urltmp = fmt.Sprintf("\\textsc{url:} \\url{%s}", value) + urltmp
+				return
 			case "urldate":
 				urltmp += fmt.Sprintf(" (accessed %s)", value)
-			default:
-				field = ex.parse(field)
-				fmt.Fprintf(bib, ",\n  %s = {%s}", field, value)
+				return
 			}
+		case DataURI:
+			urltmp = fmt.Sprintf("\\textsc{url:} \\url{%s}", value) + urltmp
+			return
 		}
+		fmt.Fprintf(bib, ",\n  %s = {%s}", ex.parse(field), value)
 	}
 
-	fields = make([]string, len(e.Optional))
-	i = 0
+	fields := make([]string, 0, len(e.Required))
+	for field := range e.Required {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := e.Required[field]; value != "" {
+			write(field, value)
+		}
+	}
+
+	fields = make([]string, 0, len(e.Optional))
 	for field := range e.Optional {
-		fields[i] = field
-		i++
+		fields = append(fields, field)
 	}
 	sort.Strings(fields)
 	for _, field := range fields {
 		if value := e.Optional[field]; value != "" && field != "abstract" {
-			switch field {
-			case "url":
-				urltmp = fmt.Sprintf("\\textsc{url:} \\url{%s}", value) + urltmp
-			case "urldate":
-				urltmp += fmt.Sprintf(" (accessed %s)", value)
-			default:
-				field = ex.parse(field)
-				fmt.Fprintf(bib, ",\n  %s = {%s}", field, value)
-			}
+			write(field, value)
 		}
 	}
 	if urltmp != "" {
 		fmt.Fprintf(bib, ",\n  %s = {%s}", "howpublished", urltmp)
 	}
 	if value, ok := e.Optional["abstract"]; ok {
-		fmt.Fprintf(bib, ",\n  %s = {%s}", "abstract", value)
+		fmt.Fprintf(bib, ",\n  %s = {%s}", "abstract", e.texField("abstract", value, ex.ascii))
 	}
-	if file := e.File; file != "" {
-		fmt.Fprintf(bib, ",\n  %s = {%s}", "file", file)
+	if len(e.Files) > 0 {
+		fmt.Fprintf(bib, ",\n  %s = {%s}", "file", bibFiles(e.Files))
 	}
 
-	bib.WriteString("\n}")
+	bib.WriteString("\n}\n")
 
-	return bib.String()
+	return bib.Flush()
+}
 
+// End implements the Exporter interface.
+func (ex *exBibtex) End(w io.Writer) error {
+	return nil
 }
 
 var monthText = map[string]string{
@@ -0,0 +1,1394 @@
+package scholar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cslNode is an element of a CSL style file.
+type cslNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []*cslNode `xml:",any"`
+	Text    string     `xml:",chardata"`
+}
+
+func (n *cslNode) name() string {
+	return n.XMLName.Local
+}
+
+func (n *cslNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *cslNode) child(name string) *cslNode {
+	for _, c := range n.Nodes {
+		if c.name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+type cslTerm struct {
+	single, multiple string
+}
+
+// CSL is a citation style defined by a CSL 1.0 style file. It supports macros,
+// names, dates, groups, conditionals, and sorting. Terms and date formats are
+// taken from a built-in en-US locale, unless the style redefines them.
+// Disambiguation and the collapsing of citations are not supported.
+type CSL struct {
+	style        *cslNode
+	macros       map[string]*cslNode
+	citation     *cslNode
+	bibliography *cslNode
+
+	terms        map[string]cslTerm
+	dates        map[string]*cslNode
+	punctInQuote bool
+}
+
+// ParseCSL parses a CSL style. It returns an ErrParse error if the style
+// cannot be read.
+func ParseCSL(r io.Reader) (*CSL, error) {
+	var root cslNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		if serr, ok := err.(*xml.SyntaxError); ok {
+			return nil, getError("ParseCSL", ErrParse, nil).
+				info(fmt.Sprintf("line %d: %s", serr.Line, serr.Msg))
+		}
+		return nil, getError("ParseCSL", ErrParse, err)
+	}
+	if root.name() != "style" {
+		return nil, getError("ParseCSL", ErrParse, nil).
+			info(fmt.Sprintf("root element is <%s>, want <style>", root.name()))
+	}
+
+	s := &CSL{
+		style:  &root,
+		macros: make(map[string]*cslNode),
+		terms:  make(map[string]cslTerm),
+		dates:  make(map[string]*cslNode),
+	}
+	s.addLocale(cslLocale)
+	for _, n := range root.Nodes {
+		switch n.name() {
+		case "macro":
+			s.macros[n.attr("name")] = n
+		case "citation":
+			s.citation = n
+		case "bibliography":
+			s.bibliography = n
+		case "locale":
+			if lang := n.attr("lang"); lang == "" || strings.HasPrefix(lang, "en") {
+				s.addLocale(n)
+			}
+		}
+	}
+	if s.citation == nil || s.citation.child("layout") == nil {
+		return nil, getError("ParseCSL", ErrParse, nil).info("no citation layout")
+	}
+	if name := s.macroCycle(); name != "" {
+		return nil, getError("ParseCSL", ErrParse, nil).
+			info(fmt.Sprintf("macro %q calls itself", name))
+	}
+
+	return s, nil
+}
+
+// macroCycle returns the name of a macro that calls itself, directly or
+// through other macros, or an empty string if there is none.
+func (s *CSL) macroCycle() string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(name string) string
+	var walk func(n *cslNode) string
+	visit = func(name string) string {
+		switch state[name] {
+		case visiting:
+			return name
+		case done:
+			return ""
+		}
+		macro, ok := s.macros[name]
+		if !ok {
+			return ""
+		}
+		state[name] = visiting
+		if cycle := walk(macro); cycle != "" {
+			return cycle
+		}
+		state[name] = done
+		return ""
+	}
+	walk = func(n *cslNode) string {
+		for _, c := range n.Nodes {
+			if name := c.attr("macro"); name != "" {
+				if cycle := visit(name); cycle != "" {
+					return cycle
+				}
+			}
+			if cycle := walk(c); cycle != "" {
+				return cycle
+			}
+		}
+		return ""
+	}
+
+	names := make([]string, 0, len(s.macros))
+	for name := range s.macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cycle := visit(name); cycle != "" {
+			return cycle
+		}
+	}
+	return ""
+}
+
+// LoadCSL parses the CSL style file.
+func LoadCSL(file string) (*CSL, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, getError("LoadCSL", ErrIO, err)
+	}
+	defer f.Close()
+
+	s, err := ParseCSL(f)
+	if err != nil {
+		return nil, getError("LoadCSL", errNotDefined, err).info(file)
+	}
+	return s, nil
+}
+
+func (s *CSL) addLocale(n *cslNode) {
+	for _, c := range n.Nodes {
+		switch c.name() {
+		case "style-options":
+			if v := c.attr("punctuation-in-quote"); v != "" {
+				s.punctInQuote = v == "true"
+			}
+		case "date":
+			s.dates[c.attr("form")] = c
+		case "terms":
+			for _, t := range c.Nodes {
+				term := cslTerm{single: t.Text, multiple: t.Text}
+				if single := t.child("single"); single != nil {
+					term.single = single.Text
+					term.multiple = single.Text
+				}
+				if multiple := t.child("multiple"); multiple != nil {
+					term.multiple = multiple.Text
+				}
+				form := t.attr("form")
+				if form == "" {
+					form = "long"
+				}
+				s.terms[t.attr("name")+"/"+form] = term
+			}
+		}
+	}
+}
+
+// term returns the term in the given form. If the form is not defined, it
+// falls back to verb-short, verb, symbol, short, and long forms as the CSL
+// specification says.
+func (s *CSL) term(name, form string, plural bool) string {
+	forms := map[string][]string{
+		"":           {"long"},
+		"long":       {"long"},
+		"short":      {"short", "long"},
+		"verb":       {"verb", "long"},
+		"verb-short": {"verb-short", "verb", "long"},
+		"symbol":     {"symbol", "short", "long"},
+	}[form]
+	for _, f := range forms {
+		if t, ok := s.terms[name+"/"+f]; ok {
+			if plural {
+				return t.multiple
+			}
+			return t.single
+		}
+	}
+	return ""
+}
+
+// inheritedOptions lists the name options that can be set in the style,
+// citation, and bibliography elements.
+var inheritedOptions = []string{
+	"and", "delimiter-precedes-et-al", "delimiter-precedes-last", "et-al-min",
+	"et-al-use-first", "et-al-use-last", "initialize", "initialize-with",
+	"name-as-sort-order", "sort-separator", "name-form", "name-delimiter",
+	"names-delimiter",
+}
+
+// options returns the name options of a citation or bibliography element.
+func (s *CSL) options(section *cslNode) map[string]string {
+	opts := make(map[string]string)
+	for _, n := range []*cslNode{s.style, section} {
+		for _, o := range inheritedOptions {
+			if v := n.attr(o); v != "" {
+				opts[o] = v
+			}
+		}
+	}
+	return opts
+}
+
+func (s *CSL) context(section *cslNode, e *Entry, number int, m Markup) *cslContext {
+	return &cslContext{
+		s:          s,
+		m:          m,
+		item:       csljson.item(e),
+		number:     number,
+		opts:       s.options(section),
+		suppressed: make(map[string]bool),
+	}
+}
+
+// order returns the entries in the order of the bibliography, and the
+// citation number of each entry.
+func (s *CSL) order(entries []*Entry) ([]*Entry, map[*Entry]int) {
+	numbers := make(map[*Entry]int)
+	for i, e := range entries {
+		numbers[e] = i + 1
+	}
+	if s.bibliography == nil {
+		return entries, numbers
+	}
+
+	entries = s.sort(s.bibliography, entries, numbers)
+	for i, e := range entries {
+		numbers[e] = i + 1
+	}
+	return entries, numbers
+}
+
+// sort sorts the entries with the sort keys of a citation or bibliography
+// element. Entries with an empty key are sorted last.
+func (s *CSL) sort(section *cslNode, entries []*Entry, numbers map[*Entry]int) []*Entry {
+	sorting := section.child("sort")
+	if sorting == nil {
+		return entries
+	}
+
+	keys := make(map[*Entry][]string)
+	for _, e := range entries {
+		c := s.context(section, e, numbers[e], Text)
+		c.sorting = true
+		for _, key := range sorting.Nodes {
+			keys[e] = append(keys[e], c.sortKey(key))
+		}
+	}
+
+	entries = append([]*Entry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := keys[entries[i]], keys[entries[j]]
+		for k, key := range sorting.Nodes {
+			switch {
+			case a[k] == b[k]:
+				continue
+			case a[k] == "":
+				return false
+			case b[k] == "":
+				return true
+			case key.attr("sort") == "descending":
+				return a[k] > b[k]
+			}
+			return a[k] < b[k]
+		}
+		return false
+	})
+	return entries
+}
+
+// Citation implements the Style interface.
+func (s *CSL) Citation(entries []*Entry, m Markup) string {
+	_, numbers := s.order(entries)
+	layout := s.citation.child("layout")
+
+	var out cslOut
+	for _, e := range s.sort(s.citation, entries, numbers) {
+		c := s.context(s.citation, e, numbers[e], m)
+		c.citation = true
+		cite := c.renderChildren(layout.Nodes, "")
+		if cite.plain == "" {
+			continue
+		}
+		if out.plain != "" {
+			out = c.join(out, c.literal(layout.attr("delimiter")))
+		}
+		out = c.join(out, cite)
+	}
+
+	c := &cslContext{s: s, m: m}
+	return strings.TrimSpace(c.format(layout, out).text)
+}
+
+// Bibliography implements the Style interface. If the style has no
+// bibliography, it returns no reference.
+func (s *CSL) Bibliography(entries []*Entry, m Markup) []string {
+	if s.bibliography == nil || s.bibliography.child("layout") == nil {
+		return nil
+	}
+	layout := s.bibliography.child("layout")
+
+	entries, numbers := s.order(entries)
+	refs := make([]string, len(entries))
+	for i, e := range entries {
+		c := s.context(s.bibliography, e, numbers[e], m)
+		refs[i] = strings.TrimSpace(c.format(layout, c.renderChildren(layout.Nodes, "")).text)
+	}
+	return refs
+}
+
+// cslOut is a rendered element. vars counts the variables called by the
+// element and found the ones that are not empty, to suppress groups.
+type cslOut struct {
+	text  string // with markup
+	plain string // without markup, to check the punctuation
+	vars  int
+	found int
+}
+
+// cslContext renders an entry with a style.
+type cslContext struct {
+	s      *CSL
+	m      Markup
+	item   map[string]interface{}
+	number int
+	opts   map[string]string
+	// suppressed lists the variables already used by a substitute.
+	suppressed map[string]bool
+	// used records the variables found, if not nil.
+	used map[string]bool
+	// sorting renders sort keys: names in sort order and dates as
+	// YYYYMMDD.
+	sorting  bool
+	citation bool
+	// macros lists the macros being rendered, so a macro that calls itself
+	// renders nothing instead of recursing.
+	macros map[string]bool
+}
+
+func (c *cslContext) literal(s string) cslOut {
+	return cslOut{text: c.m.escape(s), plain: s}
+}
+
+// join returns a followed by b. A period is not repeated after a punctuation
+// mark, and, if the locale says so, a comma or a period after a closing quote
+// is moved inside the quotes.
+func (c *cslContext) join(a, b cslOut) cslOut {
+	if a.plain != "" && b.plain != "" {
+		last, _ := utf8.DecodeLastRuneInString(a.plain)
+		first, size := utf8.DecodeRuneInString(b.plain)
+		punct := b.plain[:size]
+		trimmed := strings.HasPrefix(b.text, c.m.escape(punct))
+		q := c.s.term("close-quote", "", false)
+
+		switch {
+		case !trimmed:
+		case first == '.' && strings.ContainsRune(".?!", last):
+			b.text = strings.TrimPrefix(b.text, c.m.escape(punct))
+			b.plain = b.plain[size:]
+		case c.s.punctInQuote && (first == '.' || first == ',') && q != "" &&
+			strings.HasSuffix(a.plain, q) && strings.HasSuffix(a.text, c.m.escape(q)):
+			inner, _ := utf8.DecodeLastRuneInString(strings.TrimSuffix(a.plain, q))
+			if !strings.ContainsRune(".?!", inner) {
+				a.text = strings.TrimSuffix(a.text, c.m.escape(q)) + c.m.escape(punct) + c.m.escape(q)
+				a.plain = strings.TrimSuffix(a.plain, q) + punct + q
+			}
+			b.text = strings.TrimPrefix(b.text, c.m.escape(punct))
+			b.plain = b.plain[size:]
+		}
+	}
+
+	a.text += b.text
+	a.plain += b.plain
+	a.vars += b.vars
+	a.found += b.found
+	return a
+}
+
+// leaf returns a value with the text case of the element applied.
+func (c *cslContext) leaf(n *cslNode, v string) cslOut {
+	v = cslTextCase(n.attr("text-case"), v)
+	if n.attr("strip-periods") == "true" {
+		v = strings.Replace(v, ".", "", -1)
+	}
+	return c.literal(v)
+}
+
+// format applies the quotes, font, and affixes of the element.
+func (c *cslContext) format(n *cslNode, o cslOut) cslOut {
+	if o.plain == "" {
+		return o
+	}
+	if n.attr("quotes") == "true" {
+		open, close := c.s.term("open-quote", "", false), c.s.term("close-quote", "", false)
+		o.text = c.m.escape(open) + o.text + c.m.escape(close)
+		o.plain = open + o.plain + close
+	}
+	switch n.attr("font-style") {
+	case "italic", "oblique":
+		o.text = c.m.italic(o.text)
+	}
+	if n.attr("font-weight") == "bold" {
+		o.text = c.m.bold(o.text)
+	}
+	if n.attr("vertical-align") == "sup" {
+		o.text = c.m.superscript(o.text)
+	}
+	if p := n.attr("prefix"); p != "" {
+		o = c.join(c.literal(p), o)
+	}
+	if s := n.attr("suffix"); s != "" {
+		o = c.join(o, c.literal(s))
+	}
+	return o
+}
+
+func (c *cslContext) renderChildren(nodes []*cslNode, delim string) cslOut {
+	var out cslOut
+	for _, n := range nodes {
+		o := c.render(n)
+		if o.plain == "" {
+			out.vars += o.vars
+			out.found += o.found
+			continue
+		}
+		if out.plain != "" && delim != "" {
+			out = c.join(out, c.literal(delim))
+		}
+		out = c.join(out, o)
+	}
+	return out
+}
+
+func (c *cslContext) render(n *cslNode) cslOut {
+	switch n.name() {
+	case "text":
+		return c.renderText(n)
+	case "number":
+		return c.renderNumber(n)
+	case "label":
+		return c.renderLabel(n)
+	case "date":
+		return c.renderDate(n)
+	case "names":
+		return c.renderNames(n)
+	case "group":
+		o := c.renderChildren(n.Nodes, n.attr("delimiter"))
+		if o.vars > 0 && o.found == 0 {
+			return cslOut{vars: o.vars}
+		}
+		return c.format(n, o)
+	case "choose":
+		for _, branch := range n.Nodes {
+			switch branch.name() {
+			case "if", "else-if":
+				if c.test(branch) {
+					return c.renderChildren(branch.Nodes, "")
+				}
+			case "else":
+				return c.renderChildren(branch.Nodes, "")
+			}
+		}
+	}
+	return cslOut{}
+}
+
+// has checks if a variable of the entry is not empty.
+func (c *cslContext) has(name string) bool {
+	if c.suppressed[name] {
+		return false
+	}
+	switch name {
+	case "citation-number", "citation-key", "citation-label":
+		return true
+	}
+	switch v := c.item[name].(type) {
+	case string:
+		return v != ""
+	case []cslName:
+		return len(v) > 0
+	case cslDate:
+		return len(v.DateParts) > 0 || v.Raw != ""
+	}
+	return false
+}
+
+func (c *cslContext) use(name string) {
+	if c.used != nil {
+		c.used[name] = true
+	}
+}
+
+// variable returns the value of a variable. The short form of a variable is
+// the "-short" variable, if it exists.
+func (c *cslContext) variable(name, form string) string {
+	if c.suppressed[name] {
+		return ""
+	}
+	if form == "short" {
+		if v := c.variable(name+"-short", ""); v != "" {
+			return v
+		}
+	}
+
+	var v string
+	switch name {
+	case "citation-number":
+		v = strconv.Itoa(c.number)
+	case "citation-key", "citation-label":
+		v, _ = c.item["id"].(string)
+	default:
+		switch val := c.item[name].(type) {
+		case string:
+			v = val
+		case cslDate:
+			v = val.String()
+		}
+	}
+	if name == "page" || name == "locator" {
+		v = strings.Replace(v, "-", c.s.term("page-range-delimiter", "", false), -1)
+	}
+	if v != "" {
+		c.use(name)
+	}
+	return v
+}
+
+func (c *cslContext) renderText(n *cslNode) cslOut {
+	switch {
+	case n.attr("variable") != "":
+		v := c.variable(n.attr("variable"), n.attr("form"))
+		o := c.leaf(n, v)
+		o.vars = 1
+		if v != "" {
+			o.found = 1
+		}
+		return c.format(n, o)
+	case n.attr("macro") != "":
+		name := n.attr("macro")
+		macro, ok := c.s.macros[name]
+		if !ok || c.macros[name] {
+			return cslOut{}
+		}
+		if c.macros == nil {
+			c.macros = make(map[string]bool)
+		}
+		c.macros[name] = true
+		o := c.renderChildren(macro.Nodes, "")
+		delete(c.macros, name)
+		if tc := n.attr("text-case"); tc != "" && o.text == c.m.escape(o.plain) {
+			o.plain = cslTextCase(tc, o.plain)
+			o.text = c.m.escape(o.plain)
+		}
+		return c.format(n, o)
+	case n.attr("term") != "":
+		return c.format(n, c.leaf(n, c.s.term(n.attr("term"), n.attr("form"), n.attr("plural") == "true")))
+	}
+	return c.format(n, c.leaf(n, n.attr("value")))
+}
+
+var cslNumericRx = regexp.MustCompile(`^[a-zA-Z]*\d+[a-zA-Z]*(\s*[-–,&]\s*[a-zA-Z]*\d+[a-zA-Z]*)*$`)
+
+func (c *cslContext) renderNumber(n *cslNode) cslOut {
+	v := c.variable(n.attr("variable"), "")
+	o := cslOut{vars: 1}
+	if v == "" {
+		return o
+	}
+	if c.sorting {
+		if i, err := strconv.Atoi(v); err == nil {
+			v = fmt.Sprintf("%08d", i)
+		}
+	} else if i, err := strconv.Atoi(v); err == nil {
+		switch n.attr("form") {
+		case "ordinal":
+			v = c.ordinal(i)
+		case "long-ordinal":
+			if t := c.s.term(fmt.Sprintf("long-ordinal-%02d", i), "", false); t != "" {
+				v = t
+			} else {
+				v = c.ordinal(i)
+			}
+		case "roman":
+			v = roman(i)
+		}
+	}
+	o = c.join(o, c.leaf(n, v))
+	o.found = 1
+	return c.format(n, o)
+}
+
+func (c *cslContext) ordinal(i int) string {
+	for _, t := range []string{fmt.Sprintf("ordinal-%02d", i%100), fmt.Sprintf("ordinal-%02d", i%10), "ordinal"} {
+		if i%100 >= 11 && i%100 <= 13 && t == fmt.Sprintf("ordinal-%02d", i%10) {
+			continue
+		}
+		if suffix := c.s.term(t, "", false); suffix != "" {
+			return strconv.Itoa(i) + suffix
+		}
+	}
+	return ordinal(i)
+}
+
+// roman returns i in lower case roman numerals.
+func roman(i int) string {
+	if i <= 0 || i >= 4000 {
+		return strconv.Itoa(i)
+	}
+	numerals := []struct {
+		n int
+		s string
+	}{
+		{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+		{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+	}
+	b := new(strings.Builder)
+	for _, r := range numerals {
+		for ; i >= r.n; i -= r.n {
+			b.WriteString(r.s)
+		}
+	}
+	return b.String()
+}
+
+// labelTerms are the terms of the variables that have a different name.
+var labelTerms = map[string]string{
+	"number-of-pages": "page",
+	"chapter-number":  "chapter",
+	"locator":         "page",
+}
+
+func (c *cslContext) renderLabel(n *cslNode) cslOut {
+	name := n.attr("variable")
+	v := c.variable(name, "")
+	if v == "" {
+		return cslOut{}
+	}
+
+	plural := false
+	switch n.attr("plural") {
+	case "always":
+		plural = true
+	case "never":
+	default:
+		if i, err := strconv.Atoi(v); err == nil {
+			plural = i > 1 && name == "number-of-pages"
+		} else {
+			plural = strings.ContainsAny(v, "-–,&")
+		}
+	}
+	if t, ok := labelTerms[name]; ok {
+		name = t
+	}
+	return c.format(n, c.leaf(n, c.s.term(name, n.attr("form"), plural)))
+}
+
+func (c *cslContext) renderDate(n *cslNode) cslOut {
+	name := n.attr("variable")
+	o := cslOut{vars: 1}
+	d, ok := c.item[name].(cslDate)
+	if !ok || c.suppressed[name] || len(d.DateParts) == 0 && d.Raw == "" {
+		return o
+	}
+	c.use(name)
+	o.found = 1
+
+	if c.sorting {
+		if len(d.DateParts) == 0 {
+			return c.join(o, c.literal(d.Raw))
+		}
+		p := append(d.DateParts[0], 0, 0, 0)
+		return c.join(o, c.literal(fmt.Sprintf("%04d%02d%02d", p[0], p[1], p[2])))
+	}
+	if len(d.DateParts) == 0 {
+		return c.format(n, c.join(o, c.literal(d.Raw)))
+	}
+
+	parts, delim := c.dateParts(n)
+	var dates []cslOut
+	for _, dp := range d.DateParts {
+		var out cslOut
+		for _, part := range parts {
+			p := c.datePart(part, dp)
+			if p.plain == "" {
+				continue
+			}
+			if out.plain != "" {
+				out = c.join(out, c.literal(delim))
+			}
+			out = c.join(out, p)
+		}
+		dates = append(dates, out)
+	}
+
+	date := dates[0]
+	if len(dates) > 1 && dates[1].plain != "" {
+		date = c.join(c.join(date, c.literal("–")), dates[1])
+	}
+	return c.format(n, c.join(o, date))
+}
+
+// dateParts returns the date-part elements of a date and their delimiter. A
+// localized date uses the date format of the locale, with the attributes of
+// the date-part elements of the style.
+func (c *cslContext) dateParts(n *cslNode) ([]*cslNode, string) {
+	var local []*cslNode
+	for _, p := range n.Nodes {
+		if p.name() == "date-part" {
+			local = append(local, p)
+		}
+	}
+	format, ok := c.s.dates[n.attr("form")]
+	if !ok {
+		return local, n.attr("delimiter")
+	}
+
+	show := n.attr("date-parts")
+	if show == "" {
+		show = "year-month-day"
+	}
+	var parts []*cslNode
+	for _, p := range format.Nodes {
+		if p.name() != "date-part" || !strings.Contains(show, p.attr("name")) {
+			continue
+		}
+		merged := &cslNode{XMLName: p.XMLName, Attrs: append([]xml.Attr(nil), p.Attrs...)}
+		for _, l := range local {
+			if l.attr("name") != p.attr("name") {
+				continue
+			}
+			for _, a := range l.Attrs {
+				if a.Name.Local != "prefix" && a.Name.Local != "suffix" {
+					merged.Attrs = append([]xml.Attr{a}, merged.Attrs...)
+				}
+			}
+		}
+		parts = append(parts, merged)
+	}
+	return parts, format.attr("delimiter")
+}
+
+func (c *cslContext) datePart(n *cslNode, date []int) cslOut {
+	get := func(i int) int {
+		if i < len(date) {
+			return date[i]
+		}
+		return 0
+	}
+
+	var v string
+	switch n.attr("name") {
+	case "year":
+		y := get(0)
+		if y == 0 {
+			return cslOut{}
+		}
+		v = strconv.Itoa(y)
+		if n.attr("form") == "short" {
+			v = fmt.Sprintf("%02d", y%100)
+		}
+	case "month":
+		m := get(1)
+		if m < 1 || m > 12 {
+			return cslOut{}
+		}
+		switch n.attr("form") {
+		case "numeric":
+			v = strconv.Itoa(m)
+		case "numeric-leading-zeros":
+			v = fmt.Sprintf("%02d", m)
+		case "short":
+			v = c.s.term(fmt.Sprintf("month-%02d", m), "short", false)
+		default:
+			v = c.s.term(fmt.Sprintf("month-%02d", m), "", false)
+		}
+	case "day":
+		d := get(2)
+		if d == 0 || get(1) == 0 {
+			return cslOut{}
+		}
+		switch n.attr("form") {
+		case "numeric-leading-zeros":
+			v = fmt.Sprintf("%02d", d)
+		case "ordinal":
+			v = c.ordinal(d)
+		default:
+			v = strconv.Itoa(d)
+		}
+	}
+	return c.format(n, c.leaf(n, v))
+}
+
+func (c *cslContext) renderNames(n *cslNode) cslOut {
+	vars := strings.Fields(n.attr("variable"))
+	out := cslOut{vars: len(vars)}
+
+	var name, etAl, label *cslNode
+	labelAfter := false
+	for _, child := range n.Nodes {
+		switch child.name() {
+		case "name":
+			name = child
+		case "et-al":
+			etAl = child
+		case "label":
+			label = child
+			labelAfter = name != nil
+		}
+	}
+
+	delim := n.attr("delimiter")
+	if delim == "" {
+		delim = c.opts["names-delimiter"]
+	}
+
+	var lists []cslOut
+	for _, v := range vars {
+		names, _ := c.item[v].([]cslName)
+		if c.suppressed[v] || len(names) == 0 {
+			continue
+		}
+		c.use(v)
+		o := c.nameList(name, etAl, names)
+		if label != nil && !c.sorting {
+			if t := c.s.term(v, label.attr("form"), len(names) > 1); t != "" {
+				l := c.format(label, c.leaf(label, t))
+				if labelAfter {
+					o = c.join(o, l)
+				} else {
+					o = c.join(l, o)
+				}
+			}
+		}
+		lists = append(lists, o)
+	}
+
+	if len(lists) == 0 {
+		if sub := n.child("substitute"); sub != nil {
+			return c.substitute(n, sub, out)
+		}
+		return out
+	}
+
+	for _, o := range lists {
+		if out.plain != "" {
+			out = c.join(out, c.literal(delim))
+		}
+		out = c.join(out, o)
+	}
+	out.found = 1
+	return c.format(n, out)
+}
+
+// substitute renders the first child of the substitute element that is not
+// empty. The variables it uses are suppressed in the rest of the entry.
+func (c *cslContext) substitute(n, sub *cslNode, out cslOut) cslOut {
+	for _, child := range sub.Nodes {
+		if child.name() == "names" && len(child.Nodes) == 0 {
+			// inherit the name, et-al, and label elements
+			inherited := *child
+			for _, nn := range n.Nodes {
+				if nn.name() != "substitute" {
+					inherited.Nodes = append(inherited.Nodes, nn)
+				}
+			}
+			child = &inherited
+		}
+
+		used := c.used
+		c.used = make(map[string]bool)
+		o := c.render(child)
+		for v := range c.used {
+			c.suppressed[v] = true
+			if used != nil {
+				used[v] = true
+			}
+		}
+		c.used = used
+
+		if o.plain != "" {
+			o.vars, o.found = out.vars+o.vars, o.found+1
+			return c.format(n, o)
+		}
+	}
+	return out
+}
+
+func (c *cslContext) nameList(n, etAlNode *cslNode, names []cslName) cslOut {
+	opts := make(map[string]string)
+	for k, v := range c.opts {
+		opts[k] = v
+	}
+	if n == nil {
+		n = &cslNode{}
+	}
+	for _, a := range n.Attrs {
+		switch a.Name.Local {
+		case "delimiter":
+			opts["name-delimiter"] = a.Value
+		case "form":
+			opts["name-form"] = a.Value
+		default:
+			opts[a.Name.Local] = a.Value
+		}
+	}
+	delim, ok := opts["name-delimiter"]
+	if !ok {
+		delim = ", "
+	}
+
+	shown, etAl := names, false
+	min, _ := strconv.Atoi(opts["et-al-min"])
+	use, _ := strconv.Atoi(opts["et-al-use-first"])
+	if min > 0 && use > 0 && len(names) >= min && use < len(names) {
+		shown, etAl = names[:use], true
+	}
+
+	if opts["name-form"] == "count" {
+		if c.sorting {
+			return c.literal(fmt.Sprintf("%08d", len(shown)))
+		}
+		return c.literal(strconv.Itoa(len(shown)))
+	}
+
+	inverted := make([]bool, len(shown))
+	list := make([]cslOut, len(shown))
+	for i, name := range shown {
+		inverted[i] = c.sorting || opts["name-as-sort-order"] == "all" ||
+			opts["name-as-sort-order"] == "first" && i == 0
+		list[i] = c.name(n, name, opts, inverted[i])
+	}
+	if c.sorting {
+		var keys []string
+		for _, o := range list {
+			keys = append(keys, strings.ToLower(o.plain))
+		}
+		return c.literal(strings.Join(keys, "; "))
+	}
+
+	// precedes returns if the delimiter is written before the last name or
+	// et al., when there are count names.
+	precedes := func(rule string, count int, contextual int) bool {
+		switch rule {
+		case "always":
+			return true
+		case "never":
+			return false
+		case "after-inverted-name":
+			return inverted[len(inverted)-1]
+		}
+		return count >= contextual
+	}
+
+	and := ""
+	switch opts["and"] {
+	case "text":
+		and = c.s.term("and", "", false)
+	case "symbol":
+		and = "&"
+	}
+
+	var out cslOut
+	for i, o := range list {
+		switch {
+		case i == 0:
+		case i == len(list)-1 && !etAl && and != "":
+			if precedes(opts["delimiter-precedes-last"], len(list), 3) {
+				out = c.join(out, c.literal(delim))
+			} else {
+				out = c.join(out, c.literal(" "))
+			}
+			out = c.join(out, c.literal(and+" "))
+		default:
+			out = c.join(out, c.literal(delim))
+		}
+		out = c.join(out, o)
+	}
+
+	if etAl {
+		if opts["et-al-use-last"] == "true" && len(names)-len(shown) > 1 {
+			out = c.join(out, c.literal(delim+"… "))
+			out = c.join(out, c.name(n, names[len(names)-1], opts, opts["name-as-sort-order"] == "all"))
+		} else if etAlNode == nil || etAlNode.attr("term") != "" || len(etAlNode.Attrs) > 0 {
+			term := "et-al"
+			if etAlNode != nil && etAlNode.attr("term") != "" {
+				term = etAlNode.attr("term")
+			}
+			if precedes(opts["delimiter-precedes-et-al"], len(list), 2) {
+				out = c.join(out, c.literal(delim))
+			} else {
+				out = c.join(out, c.literal(" "))
+			}
+			t := c.literal(c.s.term(term, "", false))
+			if etAlNode != nil {
+				t = c.format(etAlNode, t)
+			}
+			out = c.join(out, t)
+		}
+	}
+	return c.format(n, out)
+}
+
+// name renders a name. Inverted names are written in sort order.
+func (c *cslContext) name(n *cslNode, name cslName, opts map[string]string, inverted bool) cslOut {
+	if name.Literal != "" {
+		return c.literal(name.Literal)
+	}
+
+	var familyNode, givenNode *cslNode
+	for _, p := range n.Nodes {
+		if p.name() == "name-part" {
+			switch p.attr("name") {
+			case "family":
+				familyNode = p
+			case "given":
+				givenNode = p
+			}
+		}
+	}
+	part := func(node *cslNode, v string) cslOut {
+		if node == nil {
+			return c.literal(v)
+		}
+		return c.format(node, c.leaf(node, v))
+	}
+
+	given := name.Given
+	if with, ok := opts["initialize-with"]; ok && !c.sorting {
+		given = cslInitials(given, with, opts["initialize"] != "false",
+			c.s.style.attr("initialize-with-hyphen") != "false")
+	}
+	given = strings.TrimSpace(given + " " + name.Dropping)
+
+	if opts["name-form"] == "short" && !c.sorting {
+		return part(familyNode, strings.TrimSpace(name.Von+" "+name.Family))
+	}
+
+	demote := c.s.style.attr("demote-non-dropping-particle")
+	var family cslOut
+	if inverted && demote != "never" && (demote != "sort-only" || c.sorting) {
+		family = part(familyNode, name.Family)
+		given = strings.TrimSpace(given + " " + name.Von)
+	} else {
+		family = part(familyNode, strings.TrimSpace(name.Von+" "+name.Family))
+	}
+
+	if given == "" {
+		return family
+	}
+	g := part(givenNode, given)
+	if inverted {
+		sep, ok := opts["sort-separator"]
+		if !ok {
+			sep = ", "
+		}
+		o := c.join(c.join(family, c.literal(sep)), g)
+		if name.Suffix != "" {
+			o = c.join(o, c.literal(sep+name.Suffix))
+		}
+		return o
+	}
+
+	o := c.join(c.join(g, c.literal(" ")), family)
+	if name.Suffix != "" {
+		o = c.join(o, c.literal(" "+name.Suffix))
+	}
+	return o
+}
+
+// cslInitials returns the initials of a given name, each followed by with. If
+// initialize is false, only the given names that are already initials are
+// written with the initialize-with string.
+func cslInitials(given, with string, initialize, hyphen bool) string {
+	var words []string
+	for _, word := range strings.Fields(given) {
+		var parts []string
+		for _, p := range strings.Split(word, "-") {
+			r, _ := utf8.DecodeRuneInString(p)
+			if p == "" {
+				continue
+			}
+			if !initialize && len(strings.TrimSuffix(p, ".")) > 1 {
+				parts = append(parts, p+" ")
+				continue
+			}
+			parts = append(parts, string(unicode.ToUpper(r))+with)
+		}
+		sep := ""
+		if hyphen {
+			sep = "-"
+		}
+		for i := range parts[:len(parts)-1] {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		words = append(words, strings.Join(parts, sep))
+	}
+	return strings.TrimSpace(strings.Join(words, ""))
+}
+
+// test checks the conditions of an if or else-if element.
+func (c *cslContext) test(n *cslNode) bool {
+	var results []bool
+	for _, a := range n.Attrs {
+		for _, v := range strings.Fields(a.Value) {
+			switch a.Name.Local {
+			case "type":
+				results = append(results, c.item["type"] == v)
+			case "variable":
+				results = append(results, c.has(v))
+			case "is-numeric":
+				results = append(results, cslNumericRx.MatchString(c.variable(v, "")))
+			case "position":
+				results = append(results, c.citation && v == "first")
+			case "is-uncertain-date", "locator", "disambiguate":
+				results = append(results, false)
+			}
+		}
+	}
+	if len(results) == 0 {
+		return false
+	}
+
+	match := n.attr("match")
+	for _, r := range results {
+		switch {
+		case match == "any" && r:
+			return true
+		case match == "none" && r:
+			return false
+		case (match == "all" || match == "") && !r:
+			return false
+		}
+	}
+	return match != "any"
+}
+
+// sortKey returns the value of a sort key of the entry.
+func (c *cslContext) sortKey(key *cslNode) string {
+	if m := key.attr("macro"); m != "" {
+		if min := key.attr("names-min"); min != "" {
+			c.opts["et-al-min"] = min
+		}
+		if use := key.attr("names-use-first"); use != "" {
+			c.opts["et-al-use-first"] = use
+		}
+		return strings.ToLower(c.render(&cslNode{
+			XMLName: xml.Name{Local: "text"},
+			Attrs:   []xml.Attr{{Name: xml.Name{Local: "macro"}, Value: m}},
+		}).plain)
+	}
+
+	v := key.attr("variable")
+	switch c.item[v].(type) {
+	case []cslName:
+		return c.renderNames(&cslNode{Attrs: []xml.Attr{{Name: xml.Name{Local: "variable"}, Value: v}}}).plain
+	case cslDate:
+		return c.renderDate(&cslNode{Attrs: []xml.Attr{{Name: xml.Name{Local: "variable"}, Value: v}}}).plain
+	}
+	value := c.variable(v, "")
+	if i, err := strconv.Atoi(value); err == nil {
+		return fmt.Sprintf("%08d", i)
+	}
+	return strings.ToLower(value)
+}
+
+// cslStopWords are not capitalized in title case, unless they are the first
+// word.
+var cslStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "but": true,
+	"by": true, "down": true, "for": true, "from": true, "in": true,
+	"into": true, "nor": true, "of": true, "on": true, "onto": true,
+	"or": true, "over": true, "so": true, "the": true, "till": true,
+	"to": true, "up": true, "via": true, "with": true, "yet": true,
+}
+
+// upperFirst returns s with its first letter in upper case.
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// cslTextCase returns s in the text case of the CSL specification.
+func cslTextCase(tc, s string) string {
+	switch tc {
+	case "lowercase":
+		return strings.ToLower(s)
+	case "uppercase":
+		return strings.ToUpper(s)
+	case "capitalize-first":
+		return upperFirst(s)
+	case "sentence":
+		if s == strings.ToUpper(s) {
+			s = strings.ToLower(s)
+		}
+		return upperFirst(s)
+	case "capitalize-all", "title":
+		words := strings.Split(s, " ")
+		for i, w := range words {
+			if tc == "title" && i > 0 && cslStopWords[w] || w != strings.ToLower(w) && tc == "title" {
+				continue
+			}
+			words[i] = upperFirst(w)
+		}
+		return strings.Join(words, " ")
+	}
+	return s
+}
+
+// cslLocale is the en-US locale used by default.
+var cslLocale = func() *cslNode {
+	var n cslNode
+	if err := xml.Unmarshal([]byte(cslLocaleEnUS), &n); err != nil {
+		panic(err)
+	}
+	return &n
+}()
+
+const cslLocaleEnUS = `<locale xml:lang="en-US">
+  <style-options punctuation-in-quote="true"/>
+  <date form="text">
+    <date-part name="month" suffix=" "/>
+    <date-part name="day" suffix=", "/>
+    <date-part name="year"/>
+  </date>
+  <date form="numeric">
+    <date-part name="month" form="numeric-leading-zeros" suffix="/"/>
+    <date-part name="day" form="numeric-leading-zeros" suffix="/"/>
+    <date-part name="year"/>
+  </date>
+  <terms>
+    <term name="accessed">accessed</term>
+    <term name="and">and</term>
+    <term name="and others">and others</term>
+    <term name="anonymous">anonymous</term>
+    <term name="anonymous" form="short">anon.</term>
+    <term name="at">at</term>
+    <term name="available at">available at</term>
+    <term name="by">by</term>
+    <term name="circa">circa</term>
+    <term name="circa" form="short">c.</term>
+    <term name="cited">cited</term>
+    <term name="et-al">et al.</term>
+    <term name="forthcoming">forthcoming</term>
+    <term name="from">from</term>
+    <term name="ibid">ibid.</term>
+    <term name="in">in</term>
+    <term name="in press">in press</term>
+    <term name="internet">internet</term>
+    <term name="no date">no date</term>
+    <term name="no date" form="short">n.d.</term>
+    <term name="online">online</term>
+    <term name="presented at">presented at the</term>
+    <term name="reference"><single>reference</single><multiple>references</multiple></term>
+    <term name="reference" form="short"><single>ref.</single><multiple>refs.</multiple></term>
+    <term name="retrieved">retrieved</term>
+    <term name="version">version</term>
+    <term name="open-quote">“</term>
+    <term name="close-quote">”</term>
+    <term name="open-inner-quote">‘</term>
+    <term name="close-inner-quote">’</term>
+    <term name="page-range-delimiter">–</term>
+    <term name="ordinal">th</term>
+    <term name="ordinal-01">st</term>
+    <term name="ordinal-02">nd</term>
+    <term name="ordinal-03">rd</term>
+    <term name="ordinal-11">th</term>
+    <term name="ordinal-12">th</term>
+    <term name="ordinal-13">th</term>
+    <term name="long-ordinal-01">first</term>
+    <term name="long-ordinal-02">second</term>
+    <term name="long-ordinal-03">third</term>
+    <term name="long-ordinal-04">fourth</term>
+    <term name="long-ordinal-05">fifth</term>
+    <term name="long-ordinal-06">sixth</term>
+    <term name="long-ordinal-07">seventh</term>
+    <term name="long-ordinal-08">eighth</term>
+    <term name="long-ordinal-09">ninth</term>
+    <term name="long-ordinal-10">tenth</term>
+    <term name="chapter"><single>chapter</single><multiple>chapters</multiple></term>
+    <term name="chapter" form="short"><single>chap.</single><multiple>chaps.</multiple></term>
+    <term name="edition"><single>edition</single><multiple>editions</multiple></term>
+    <term name="edition" form="short"><single>ed.</single><multiple>eds.</multiple></term>
+    <term name="figure"><single>figure</single><multiple>figures</multiple></term>
+    <term name="figure" form="short"><single>fig.</single><multiple>figs.</multiple></term>
+    <term name="issue"><single>issue</single><multiple>issues</multiple></term>
+    <term name="issue" form="short"><single>no.</single><multiple>nos.</multiple></term>
+    <term name="number"><single>number</single><multiple>numbers</multiple></term>
+    <term name="number" form="short"><single>no.</single><multiple>nos.</multiple></term>
+    <term name="page"><single>page</single><multiple>pages</multiple></term>
+    <term name="page" form="short"><single>p.</single><multiple>pp.</multiple></term>
+    <term name="paragraph"><single>paragraph</single><multiple>paragraphs</multiple></term>
+    <term name="paragraph" form="short"><single>para.</single><multiple>paras.</multiple></term>
+    <term name="section"><single>section</single><multiple>sections</multiple></term>
+    <term name="section" form="short"><single>sec.</single><multiple>secs.</multiple></term>
+    <term name="volume"><single>volume</single><multiple>volumes</multiple></term>
+    <term name="volume" form="short"><single>vol.</single><multiple>vols.</multiple></term>
+    <term name="editor"><single>editor</single><multiple>editors</multiple></term>
+    <term name="editor" form="short"><single>ed.</single><multiple>eds.</multiple></term>
+    <term name="editor" form="verb">edited by</term>
+    <term name="editor" form="verb-short">ed. by</term>
+    <term name="collection-editor"><single>editor</single><multiple>editors</multiple></term>
+    <term name="collection-editor" form="short"><single>ed.</single><multiple>eds.</multiple></term>
+    <term name="collection-editor" form="verb">edited by</term>
+    <term name="container-author" form="verb">by</term>
+    <term name="translator"><single>translator</single><multiple>translators</multiple></term>
+    <term name="translator" form="short"><single>trans.</single><multiple>trans.</multiple></term>
+    <term name="translator" form="verb">translated by</term>
+    <term name="translator" form="verb-short">trans. by</term>
+    <term name="editortranslator"><single>editor &amp; translator</single><multiple>editors &amp; translators</multiple></term>
+    <term name="editortranslator" form="verb">edited &amp; translated by</term>
+    <term name="month-01">January</term>
+    <term name="month-02">February</term>
+    <term name="month-03">March</term>
+    <term name="month-04">April</term>
+    <term name="month-05">May</term>
+    <term name="month-06">June</term>
+    <term name="month-07">July</term>
+    <term name="month-08">August</term>
+    <term name="month-09">September</term>
+    <term name="month-10">October</term>
+    <term name="month-11">November</term>
+    <term name="month-12">December</term>
+    <term name="month-01" form="short">Jan.</term>
+    <term name="month-02" form="short">Feb.</term>
+    <term name="month-03" form="short">Mar.</term>
+    <term name="month-04" form="short">Apr.</term>
+    <term name="month-05" form="short">May</term>
+    <term name="month-06" form="short">Jun.</term>
+    <term name="month-07" form="short">Jul.</term>
+    <term name="month-08" form="short">Aug.</term>
+    <term name="month-09" form="short">Sep.</term>
+    <term name="month-10" form="short">Oct.</term>
+    <term name="month-11" form="short">Nov.</term>
+    <term name="month-12" form="short">Dec.</term>
+    <term name="season-01">Spring</term>
+    <term name="season-02">Summer</term>
+    <term name="season-03">Autumn</term>
+    <term name="season-04">Winter</term>
+  </terms>
+</locale>`
@@ -0,0 +1,215 @@
+package scholar
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const mockCSLAuthorDate = `<?xml version="1.0" encoding="utf-8"?>
+<style xmlns="http://purl.org/net/xbiblio/csl" class="in-text" version="1.0"
+  demote-non-dropping-particle="sort-only">
+  <info><title>Test Author-Date</title></info>
+  <macro name="author">
+    <names variable="author">
+      <name name-as-sort-order="first" and="text" delimiter-precedes-last="always"
+        initialize-with=". "/>
+      <label form="short" prefix=" (" suffix=")"/>
+      <substitute>
+        <names variable="editor"/>
+        <text variable="title"/>
+      </substitute>
+    </names>
+  </macro>
+  <macro name="author-short">
+    <names variable="author">
+      <name form="short" and="symbol"/>
+      <substitute>
+        <names variable="editor"/>
+        <text variable="title" form="short" quotes="true"/>
+      </substitute>
+    </names>
+  </macro>
+  <macro name="year">
+    <choose>
+      <if variable="issued">
+        <date variable="issued">
+          <date-part name="year"/>
+        </date>
+      </if>
+      <else>
+        <text term="no date" form="short"/>
+      </else>
+    </choose>
+  </macro>
+  <macro name="title">
+    <choose>
+      <if type="book">
+        <text variable="title" font-style="italic"/>
+      </if>
+      <else>
+        <text variable="title" quotes="true"/>
+      </else>
+    </choose>
+  </macro>
+  <citation et-al-min="3" et-al-use-first="1">
+    <sort>
+      <key macro="author"/>
+      <key macro="year"/>
+    </sort>
+    <layout prefix="(" suffix=")" delimiter="; ">
+      <group delimiter=", ">
+        <text macro="author-short"/>
+        <text macro="year"/>
+      </group>
+    </layout>
+  </citation>
+  <bibliography>
+    <sort>
+      <key macro="author"/>
+      <key variable="issued" sort="descending"/>
+    </sort>
+    <layout suffix=".">
+      <group delimiter=". ">
+        <text macro="author"/>
+        <text macro="year"/>
+        <text macro="title"/>
+        <group delimiter=" ">
+          <text variable="container-title" font-style="italic"/>
+          <group delimiter=", ">
+            <text variable="volume"/>
+            <group>
+              <label variable="page" form="short" suffix=" "/>
+              <text variable="page"/>
+            </group>
+          </group>
+        </group>
+        <group delimiter=": ">
+          <text variable="publisher-place"/>
+          <text variable="publisher"/>
+        </group>
+        <group>
+          <number variable="edition" form="ordinal"/>
+          <text term="edition" form="short" prefix=" "/>
+        </group>
+        <date variable="issued" form="text" date-parts="year-month-day"/>
+      </group>
+    </layout>
+  </bibliography>
+</style>`
+
+const mockCSLNumeric = `<?xml version="1.0" encoding="utf-8"?>
+<style xmlns="http://purl.org/net/xbiblio/csl" class="in-text" version="1.0">
+  <citation>
+    <layout vertical-align="sup" delimiter=",">
+      <text variable="citation-number"/>
+    </layout>
+  </citation>
+  <bibliography et-al-min="3" et-al-use-first="1">
+    <layout>
+      <text variable="citation-number" suffix=". "/>
+      <names variable="author" suffix=" ">
+        <name initialize-with="." delimiter=", ">
+          <name-part name="family" text-case="uppercase"/>
+        </name>
+        <et-al font-style="italic"/>
+      </names>
+      <text variable="title" text-case="lowercase" font-weight="bold"/>
+    </layout>
+  </bibliography>
+</style>`
+
+func TestCSL(t *testing.T) {
+	entries := mockReferences(t)
+	misc, err := NewEntry("misc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	misc.Required["title"] = "Anonymous Notes"
+	misc.Required["date"] = "2010"
+
+	tests := []struct {
+		style    string
+		markup   Markup
+		entries  []*Entry
+		citation string
+		refs     []string
+	}{
+		{mockCSLAuthorDate, Text, entries,
+			"(Knuth, 1984; Last et al., 2006)",
+			[]string{
+				"Knuth, D. E. 1984. The TeXbook. Reading, MA: Addison-Wesley. 2nd ed. 1984.",
+				"Last, F. M., J.-P. von Other, and A. Third. 2006. “Why & How?” The Journal 3, pp. 10–20. March 2, 2006.",
+			},
+		},
+		{mockCSLAuthorDate, HTML, entries,
+			"(Knuth, 1984; Last et al., 2006)",
+			[]string{
+				"Knuth, D. E. 1984. <i>The TeXbook</i>. Reading, MA: Addison-Wesley. 2nd ed. 1984.",
+				"Last, F. M., J.-P. von Other, and A. Third. 2006. “Why &amp; How?” <i>The Journal</i> 3, pp. 10–20. March 2, 2006.",
+			},
+		},
+		{mockCSLAuthorDate, Text, []*Entry{misc},
+			"(“Anonymous Notes,” 2010)",
+			[]string{
+				"Anonymous Notes. 2010. 2010.",
+			},
+		},
+		{mockCSLNumeric, HTML, entries,
+			"<sup>1,2</sup>",
+			[]string{
+				"1. D.E. KNUTH <b>the texbook</b>",
+				"2. F.M. LAST <i>et al.</i> <b>why &amp; how?</b>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseCSL(strings.NewReader(tt.style))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Citation(tt.entries, tt.markup); got != tt.citation {
+			t.Errorf("Citation(%v) = %q, want %q", tt.markup, got, tt.citation)
+		}
+		got := s.Bibliography(tt.entries, tt.markup)
+		if strings.Join(got, "\n") != strings.Join(tt.refs, "\n") {
+			t.Errorf("Bibliography(%v) = %q, want %q", tt.markup, got, tt.refs)
+		}
+	}
+}
+
+func TestParseCSL_Error(t *testing.T) {
+	tests := []string{
+		"<style><citation>",
+		"<locale/>",
+		"<style><bibliography><layout/></bibliography></style>",
+		`<style><macro name="a"><text macro="a"/></macro>` +
+			`<citation><layout><text macro="a"/></layout></citation></style>`,
+		`<style><macro name="a"><group><text macro="b"/></group></macro>` +
+			`<macro name="b"><text macro="a"/></macro>` +
+			`<citation><layout><text variable="title"/></layout></citation></style>`,
+	}
+	for _, style := range tests {
+		if _, err := ParseCSL(strings.NewReader(style)); !IsError(ErrParse, err) {
+			t.Errorf("ParseCSL(%q) error = %v, want ErrParse", style, err)
+		}
+	}
+}
+
+func TestCSL_MacroCycle(t *testing.T) {
+	s, err := ParseCSL(strings.NewReader(`<style>` +
+		`<macro name="title"><text variable="title"/></macro>` +
+		`<citation><layout><text macro="title"/></layout></citation></style>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A cycle added after parsing renders nothing instead of recursing
+	s.macros["title"].Nodes = append(s.macros["title"].Nodes,
+		&cslNode{XMLName: xml.Name{Local: "text"}, Attrs: []xml.Attr{{Name: xml.Name{Local: "macro"}, Value: "title"}}})
+	c := &cslContext{s: s, m: Text, item: map[string]interface{}{"title": "The Title"}}
+	if got := c.renderChildren(s.citation.child("layout").Nodes, "").plain; got != "The Title" {
+		t.Errorf("got %q, want %q", got, "The Title")
+	}
+}
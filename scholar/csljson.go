@@ -0,0 +1,497 @@
+package scholar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+type exCSL struct {
+	types  map[string]string
+	fields map[string]string
+}
+
+// cslName is a name in CSL-JSON format. Names without first name enclosed in
+// braces, such as {World Health Organization}, are written as literals.
+type cslName struct {
+	Family   string `json:"family,omitempty"`
+	Given    string `json:"given,omitempty"`
+	Dropping string `json:"dropping-particle,omitempty"`
+	Von      string `json:"non-dropping-particle,omitempty"`
+	Suffix   string `json:"suffix,omitempty"`
+	Literal  string `json:"literal,omitempty"`
+}
+
+// cslDate is a date in CSL-JSON format. Ranges of dates have two date parts.
+type cslDate struct {
+	DateParts [][]int `json:"date-parts,omitempty"`
+	Raw       string  `json:"raw,omitempty"`
+}
+
+func newCSLName(n Name) cslName {
+	if n.First == "" && n.Von == "" && n.Jr == "" && strings.HasPrefix(n.Last, "{") {
+		return cslName{Literal: unbrace(n.Last)}
+	}
+	return cslName{
+		Family: unbrace(n.Last),
+		Given:  unbrace(n.First),
+		Von:    unbrace(n.Von),
+		Suffix: unbrace(n.Jr),
+	}
+}
+
+// name returns the name in BibTeX format.
+func (n cslName) name() Name {
+	if n.Literal != "" {
+		return Name{Last: "{" + n.Literal + "}"}
+	}
+	return Name{
+		First: n.Given,
+		Von:   strings.TrimSpace(n.Dropping + " " + n.Von),
+		Last:  n.Family,
+		Jr:    n.Suffix,
+	}
+}
+
+func newCSLDate(value string) cslDate {
+	var parts [][]int
+	for _, v := range strings.Split(value, "/") {
+		if v == "" {
+			continue
+		}
+		d, err := ParseDate(v)
+		if err != nil {
+			return cslDate{Raw: value}
+		}
+		p := []int{d.Year}
+		if d.Month != 0 {
+			p = append(p, d.Month)
+			if d.Day != 0 {
+				p = append(p, d.Day)
+			}
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return cslDate{Raw: value}
+	}
+	return cslDate{DateParts: parts}
+}
+
+// item returns the CSL-JSON item of the entry. Fields without a CSL variable
+// are skipped.
+func (ex *exCSL) item(e *Entry) map[string]interface{} {
+	item := make(map[string]interface{})
+	item["id"] = e.GetKey()
+	if t, ok := ex.types[e.Type]; ok {
+		item["type"] = t
+	} else {
+		item["type"] = "document"
+	}
+
+	fields := make(map[string]string)
+	for field, value := range e.Optional {
+		fields[field] = value
+	}
+	for field, value := range e.Required {
+		fields[field] = value
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		value := strings.TrimSpace(fields[field])
+		if value == "" {
+			continue
+		}
+		v, ok := ex.fields[field]
+		if !ok {
+			continue
+		}
+		if field == "number" && e.Type == "article" {
+			v = "issue"
+		}
+
+		switch e.Datatype(field) {
+		case DataNameList:
+			var list []cslName
+			for _, n := range ParseNames(value) {
+				if !n.Others() {
+					list = append(list, newCSLName(n))
+				}
+			}
+			item[v] = list
+		case DataDate:
+			item[v] = newCSLDate(value)
+		case DataRange:
+			ranges, err := ParseRanges(value)
+			if err != nil {
+				item[v] = value
+				continue
+			}
+			s := make([]string, len(ranges))
+			for i, r := range ranges {
+				s[i] = strings.Replace(r.String(), "--", "-", 1)
+			}
+			item[v] = strings.Join(s, ", ")
+		default:
+			if _, ok := item[v]; ok && field != v {
+				// fields named as the CSL variable take precedence,
+				// otherwise the first field in alphabetical order
+				continue
+			}
+			item[v] = unbrace(value)
+		}
+	}
+
+	return item
+}
+
+func (ex *exCSL) marshal(v interface{}) string {
+	b := new(bytes.Buffer)
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Begin implements the Exporter interface. The entries are written as a
+// JSON array of CSL-JSON items.
+func (ex *exCSL) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+// Entry implements the Exporter interface.
+func (ex *exCSL) Entry(w io.Writer, e *Entry, i int) error {
+	sep := "\n  "
+	if i > 0 {
+		sep = "," + sep
+	}
+	item := strings.Replace(ex.marshal(ex.item(e)), "\n", "\n  ", -1)
+	_, err := io.WriteString(w, sep+item)
+	return err
+}
+
+// End implements the Exporter interface.
+func (ex *exCSL) End(w io.Writer) error {
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+// String returns the date in YYYY[-MM[-DD]] format. Ranges of dates are
+// separated by '/'.
+func (d cslDate) String() string {
+	var dates []string
+	for _, p := range d.DateParts {
+		var date Date
+		if len(p) == 0 || p[0] == 0 {
+			continue
+		}
+		date.Year = p[0]
+		if len(p) > 1 {
+			date.Month = p[1]
+		}
+		if len(p) > 2 && date.Month != 0 {
+			date.Day = p[2]
+		}
+		dates = append(dates, date.String())
+	}
+	if len(dates) == 0 {
+		return d.Raw
+	}
+	return strings.Join(dates, "/")
+}
+
+type imCSL struct {
+	types  map[string]string
+	fields map[string]string
+}
+
+// read parses a CSL-JSON array of items, or a single item. Items of a type
+// that is not loaded are read as "misc" entries. Variables without a field
+// are kept as optional fields with the same name.
+func (im *imCSL) read(r io.Reader) ([]*Entry, error) {
+	d, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, getError("Import", ErrIO, err)
+	}
+
+	var items []map[string]json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(d), []byte("{")) {
+		items = make([]map[string]json.RawMessage, 1)
+		err = json.Unmarshal(d, &items[0])
+	} else {
+		err = json.Unmarshal(d, &items)
+	}
+	if err != nil {
+		if serr, ok := err.(*json.SyntaxError); ok {
+			line := 1 + bytes.Count(d[:serr.Offset], []byte("\n"))
+			return nil, getError("Import", ErrParse, err).
+				info(fmt.Sprintf("line %d", line))
+		}
+		return nil, getError("Import", ErrParse, err)
+	}
+
+	entries := make([]*Entry, 0, len(items))
+	for i, item := range items {
+		e, err := im.entry(item)
+		if err != nil {
+			return nil, getError("Import", errNotDefined, err).
+				info(fmt.Sprintf("item %d", i+1))
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func (im *imCSL) entry(item map[string]json.RawMessage) (*Entry, error) {
+	var typ, id, key string
+	json.Unmarshal(item["type"], &typ)
+	json.Unmarshal(item["id"], &id)
+	json.Unmarshal(item["citation-key"], &key)
+	if key == "" {
+		key = id
+	}
+
+	t, ok := im.types[typ]
+	if !ok {
+		t = typ
+	}
+	e, err := NewEntry(t)
+	if IsError(ErrTypeNotFound, err) {
+		e, err = NewEntry("misc")
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Key = key
+
+	vars := make([]string, 0, len(item))
+	for v := range item {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+
+	for _, v := range vars {
+		switch v {
+		case "type", "id", "citation-key":
+			continue
+		}
+
+		value := im.value(item[v])
+		if value == "" || value == "null" || value == "[]" {
+			continue
+		}
+		field := im.field(e, v)
+		e.Set(field, e.formatField(field, value))
+	}
+
+	return e, nil
+}
+
+// field returns the field of the entry for a CSL variable.
+func (im *imCSL) field(e *Entry, v string) string {
+	et := EntryTypes[e.Type]
+	has := func(field string) bool {
+		return et != nil && et.Field(field) != nil
+	}
+
+	switch v {
+	case "container-title":
+		if !has("journaltitle") && has("booktitle") {
+			return "booktitle"
+		}
+	case "publisher":
+		if !has("publisher") && has("institution") {
+			return "institution"
+		}
+	}
+
+	if f, ok := im.fields[v]; ok {
+		return f
+	}
+	return v
+}
+
+// value returns the value of a CSL variable as a field value. Names are
+// written in BibTeX format and dates in YYYY[-MM[-DD]] format. Other values
+// are kept as JSON.
+func (im *imCSL) value(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+
+	var names []cslName
+	if err := json.Unmarshal(raw, &names); err == nil && len(names) > 0 {
+		list := make([]Name, 0, len(names))
+		for _, n := range names {
+			if name := n.name(); name != (Name{}) {
+				list = append(list, name)
+			}
+		}
+		if len(list) == len(names) {
+			return FormatNames(list)
+		}
+	}
+
+	var date struct {
+		DateParts [][]json.Number `json:"date-parts"`
+		Raw       string          `json:"raw"`
+		Literal   string          `json:"literal"`
+	}
+	if err := json.Unmarshal(raw, &date); err == nil {
+		d := cslDate{Raw: date.Raw}
+		if d.Raw == "" {
+			d.Raw = date.Literal
+		}
+		for _, parts := range date.DateParts {
+			var p []int
+			for _, v := range parts {
+				i, err := v.Int64()
+				if err != nil {
+					break
+				}
+				p = append(p, int(i))
+			}
+			d.DateParts = append(d.DateParts, p)
+		}
+		if date := d.String(); date != "" {
+			return date
+		}
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.Compact(b, raw); err != nil {
+		return string(raw)
+	}
+	return b.String()
+}
+
+var csljson = &exCSL{
+	types: map[string]string{
+		"article":       "article-journal",
+		"book":          "book",
+		"mvbook":        "book",
+		"inbook":        "chapter",
+		"collection":    "book",
+		"incollection":  "chapter",
+		"inproceedings": "paper-conference",
+		"proceedings":   "book",
+		"report":        "report",
+		"thesis":        "thesis",
+		"manual":        "report",
+		"misc":          "document",
+		"online":        "webpage",
+		"patent":        "patent",
+		"dataset":       "dataset",
+		"software":      "software",
+		"unpublished":   "manuscript",
+	},
+	fields: map[string]string{
+		"author":       "author",
+		"editor":       "editor",
+		"translator":   "translator",
+		"bookauthor":   "container-author",
+		"holder":       "authority",
+		"title":        "title",
+		"shorttitle":   "title-short",
+		"journaltitle": "container-title",
+		"booktitle":    "container-title",
+		"maintitle":    "container-title",
+		"series":       "collection-title",
+		"eventtitle":   "event-title",
+		"venue":        "event-place",
+		"date":         "issued",
+		"urldate":      "accessed",
+		"origdate":     "original-date",
+		"eventdate":    "event-date",
+		"volume":       "volume",
+		"volumes":      "number-of-volumes",
+		"number":       "number",
+		"pages":        "page",
+		"pagetotal":    "number-of-pages",
+		"chapter":      "chapter-number",
+		"edition":      "edition",
+		"version":      "version",
+		"publisher":    "publisher",
+		"institution":  "publisher",
+		"organization": "publisher",
+		"location":     "publisher-place",
+		"type":         "genre",
+		"doi":          "DOI",
+		"isbn":         "ISBN",
+		"issn":         "ISSN",
+		"url":          "URL",
+		"abstract":     "abstract",
+		"note":         "note",
+		"keywords":     "keyword",
+		"language":     "language",
+		"langid":       "language",
+	},
+}
+
+var csljsonParser = &imCSL{
+	types: map[string]string{
+		"article":           "article",
+		"article-journal":   "article",
+		"article-magazine":  "article",
+		"article-newspaper": "article",
+		"book":              "book",
+		"chapter":           "inbook",
+		"paper-conference":  "inproceedings",
+		"report":            "report",
+		"thesis":            "thesis",
+		"document":          "misc",
+		"webpage":           "online",
+		"post":              "online",
+		"post-weblog":       "online",
+		"patent":            "patent",
+		"dataset":           "dataset",
+		"software":          "software",
+		"manuscript":        "unpublished",
+	},
+	fields: map[string]string{
+		"container-author":  "bookauthor",
+		"authority":         "holder",
+		"title-short":       "shorttitle",
+		"container-title":   "journaltitle",
+		"collection-title":  "series",
+		"event-title":       "eventtitle",
+		"event-place":       "venue",
+		"issued":            "date",
+		"accessed":          "urldate",
+		"original-date":     "origdate",
+		"event-date":        "eventdate",
+		"number-of-volumes": "volumes",
+		"issue":             "number",
+		"page":              "pages",
+		"number-of-pages":   "pagetotal",
+		"chapter-number":    "chapter",
+		"publisher-place":   "location",
+		"genre":             "type",
+		"DOI":               "doi",
+		"ISBN":              "isbn",
+		"ISSN":              "issn",
+		"URL":               "url",
+		"keyword":           "keywords",
+	},
+}
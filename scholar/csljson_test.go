@@ -0,0 +1,145 @@
+package scholar
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExCSL_ExportList(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["author"] = "von Neumann, Jr., John and {World Health Organization} and others"
+	entry.Required["date"] = "2006-03-01"
+	entry.Optional["doi"] = "10.1000/182"
+	entry.Optional["pages"] = "1--10"
+	entry.Optional["number"] = "3"
+	entry.Optional["obscure"] = "not in CSL"
+
+	out, err := ExportList([]*Entry{entry}, "csljson")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("ExportList() is not a JSON array: %v\n%s", err, out)
+	}
+
+	want := []map[string]interface{}{{
+		"id":   "neumann2006",
+		"type": "article-journal",
+		"author": []interface{}{
+			map[string]interface{}{"family": "Neumann", "given": "John", "non-dropping-particle": "von", "suffix": "Jr."},
+			map[string]interface{}{"literal": "World Health Organization"},
+		},
+		"title":           "The Title",
+		"container-title": "The Journal",
+		"issued":          map[string]interface{}{"date-parts": []interface{}{[]interface{}{2006.0, 3.0, 1.0}}},
+		"DOI":             "10.1000/182",
+		"page":            "1-10",
+		"issue":           "3",
+	}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportList() =\n%s\nwant:\n%v", out, want)
+	}
+}
+
+func TestNewCSLDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want cslDate
+	}{
+		{"2006", cslDate{DateParts: [][]int{{2006}}}},
+		{"2006-03", cslDate{DateParts: [][]int{{2006, 3}}}},
+		{"1988/1992-05", cslDate{DateParts: [][]int{{1988}, {1992, 5}}}},
+		{"spring 2006", cslDate{Raw: "spring 2006"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := newCSLDate(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newCSLDate(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImport_CSLJSON(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["author"] = "von Neumann, Jr., John and {World Health Organization}"
+	entry.Required["date"] = "2006-03-01"
+	entry.Optional["doi"] = "10.1000/182"
+	entry.Optional["pages"] = "1--10"
+	entry.Optional["number"] = "3"
+
+	out, err := ExportList([]*Entry{entry}, "csljson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Import(strings.NewReader(out), "csljson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Import() returned %d entries, want 1", len(entries))
+	}
+
+	got := entries[0]
+	if got.Type != "article" || got.Key != entry.Key {
+		t.Errorf("got @%s{%s}, want @article{%s}", got.Type, got.Key, entry.Key)
+	}
+	for _, field := range []string{"author", "title", "journaltitle", "date", "doi", "pages", "number"} {
+		if got.field(field) != entry.field(field) {
+			t.Errorf("%s = %q, want %q", field, got.field(field), entry.field(field))
+		}
+	}
+}
+
+func TestImport_CSLJSONUnmapped(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	in := `{
+  "id": "item1",
+  "type": "motion_picture",
+  "title": "A Film",
+  "issued": {"date-parts": [["1999", "5"]]},
+  "PMID": 12345,
+  "categories": ["a", "b"],
+  "director": [{"family": "Doe", "given": "Jane"}]
+}`
+	entries, err := Import(strings.NewReader(in), "csljson")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := entries[0]
+	want := map[string]string{
+		"title":      "A Film",
+		"date":       "1999-05",
+		"PMID":       "12345",
+		"categories": `["a","b"]`,
+		"director":   "Doe, Jane",
+	}
+	if e.Type != "misc" {
+		t.Errorf("Type = %q, want misc", e.Type)
+	}
+	for field, value := range want {
+		if got := e.field(field); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+
+	_, err = Import(strings.NewReader("[\n  {\"id\": 1,}\n]"), "csljson")
+	if !IsError(ErrParse, err) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Import() error = %v, want ErrParse at line 2", err)
+	}
+}
@@ -0,0 +1,307 @@
+package scholar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Duplicates is a group of entries that are likely the same work. Score is
+// the confidence, from 0 to 1, that the entries are duplicates. It is the
+// lowest similarity of the pairs of entries of the group.
+type Duplicates struct {
+	Entries []*Entry
+	Score   float64
+}
+
+// fingerprint holds the normalized values of an entry used to compare it
+// with other entries.
+type fingerprint struct {
+	doi     string
+	isbn    string
+	title   map[string]bool
+	authors map[string]bool
+	year    int
+	edition string
+	volume  string
+}
+
+// maxEditionScore is the highest similarity of entries that look like
+// different editions or volumes of the same work.
+const maxEditionScore = 0.5
+
+func newFingerprint(e *Entry) *fingerprint {
+	f := &fingerprint{
+		doi:     normalizeDOI(e.field("doi")),
+		isbn:    normalizeISBN(e.field("isbn")),
+		title:   bigrams(strings.Join(titleWords(e.field("title")), " ")),
+		authors: make(map[string]bool),
+		edition: normalizeEdition(e.field("edition")),
+		volume:  strings.ToLower(strings.TrimSpace(e.field("volume"))),
+	}
+	for _, n := range e.authorsOrEditors() {
+		if last := strings.ToLower(transliterate(n.LastName())); last != "" {
+			f.authors[last] = true
+		}
+	}
+	f.year, _ = strconv.Atoi(e.Year())
+
+	return f
+}
+
+// normalizeDOI returns the DOI in lower case, without the resolver URL or the
+// "doi:" prefix.
+func normalizeDOI(doi string) string {
+	doi = strings.ToLower(strings.TrimSpace(doi))
+	for _, prefix := range []string{"https://", "http://", "dx.", "doi.org/", "doi:"} {
+		doi = strings.TrimPrefix(doi, prefix)
+	}
+	return strings.TrimSpace(doi)
+}
+
+// normalizeEdition returns the number of an edition, such as "2" for "2nd ed.",
+// or the edition in lower case if it has no number.
+func normalizeEdition(edition string) string {
+	edition = strings.ToLower(strings.TrimSpace(edition))
+	if i := strings.IndexAny(edition, "0123456789"); i >= 0 {
+		j := i
+		for j < len(edition) && edition[j] >= '0' && edition[j] <= '9' {
+			j++
+		}
+		return edition[i:j]
+	}
+	return edition
+}
+
+// normalizeISBN returns the ISBN-13 of an ISBN, so ISBN-10 and ISBN-13 of the
+// same book match. Invalid ISBNs are returned without hyphens and spaces.
+func normalizeISBN(isbn string) string {
+	d := isbnDigits(strings.TrimSpace(isbn))
+	if len(d) != 10 || checkISBN(d) != nil {
+		return d
+	}
+
+	d = "978" + d[:9]
+	sum := 0
+	for i, r := range d {
+		v := int(r - '0')
+		if i%2 == 1 {
+			v *= 3
+		}
+		sum += v
+	}
+	return d + strconv.Itoa((10-sum%10)%10)
+}
+
+// bigrams returns the set of pairs of consecutive letters of s.
+func bigrams(s string) map[string]bool {
+	rs := []rune(s)
+	set := make(map[string]bool)
+	for i := 0; i+1 < len(rs); i++ {
+		set[string(rs[i:i+2])] = true
+	}
+	return set
+}
+
+// overlap returns the fraction of the smaller set found in the other set. If
+// any set is empty, it returns -1.
+func overlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return -1
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	n := 0
+	for k := range a {
+		if b[k] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
+
+// dice returns the Sørensen–Dice coefficient of two sets. If any set is
+// empty, it returns -1.
+func dice(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return -1
+	}
+	n := 0
+	for k := range a {
+		if b[k] {
+			n++
+		}
+	}
+	return 2 * float64(n) / float64(len(a)+len(b))
+}
+
+func (f *fingerprint) similarity(g *fingerprint) float64 {
+	if f.doi != "" && f.doi == g.doi {
+		return 1
+	}
+
+	title := dice(f.title, g.title)
+	if title < 0 {
+		// Entries without a title cannot be compared
+		return 0
+	}
+
+	authors := overlap(f.authors, g.authors)
+	if authors < 0 {
+		authors = 0.5
+	}
+
+	year := 0.5
+	sameYear := true
+	if f.year != 0 && g.year != 0 {
+		switch f.year - g.year {
+		case 0:
+			year = 1
+		case -1, 1:
+			year = 0.5
+		default:
+			year = 0
+			sameYear = false
+		}
+	}
+	sameEdition := (f.edition == "" || g.edition == "" || f.edition == g.edition) &&
+		(f.volume == "" || g.volume == "" || f.volume == g.volume)
+
+	score := 0.6*title + 0.25*authors + 0.15*year
+	switch {
+	case f.isbn != "" && f.isbn == g.isbn && title >= 0.5:
+		// Chapters of a book share its ISBN, so the titles must match too
+		if score < 0.95 {
+			score = 0.95
+		}
+	case !sameYear || !sameEdition:
+		// Editions of a book years apart, or volumes of a work, share
+		// their titles and authors
+		if score > maxEditionScore {
+			score = maxEditionScore
+		}
+	case f.doi != "" && g.doi != "":
+		// Different DOIs are likely different works, or a preprint and
+		// its published version
+		score *= 0.7
+	}
+
+	return score
+}
+
+// Similarity returns how likely it is, from 0 to 1, that two entries are the
+// same work. Entries with the same DOI are duplicates. Otherwise, the score
+// is based on the similarity of their titles, the overlap of their authors,
+// and their years. Matching ISBNs increase the score, while different DOIs
+// decrease it. Entries with years more than one year apart, or with different
+// editions or volumes, score at most 0.5.
+func Similarity(a, b *Entry) float64 {
+	return newFingerprint(a).similarity(newFingerprint(b))
+}
+
+// FindDuplicates groups the entries that are probable duplicates, that is,
+// whose similarity is at least threshold (see Similarity). Every entry of a
+// group is a probable duplicate of every other entry of the group, so entries
+// that are not duplicates, such as two editions of a book, are never grouped
+// through a third entry similar to both. Groups are sorted by score, from the
+// most likely duplicates, and the entries of each group are sorted by key.
+func FindDuplicates(entries []*Entry, threshold float64) []Duplicates {
+	fs := make([]*fingerprint, len(entries))
+	for i, e := range entries {
+		fs[i] = newFingerprint(e)
+	}
+
+	type pair struct {
+		i, j  int
+		score float64
+	}
+	var pairs []pair
+	scores := make(map[[2]int]float64)
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			if s := fs[i].similarity(fs[j]); s >= threshold {
+				pairs = append(pairs, pair{i, j, s})
+				scores[[2]int{i, j}] = s
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].score > pairs[b].score
+	})
+
+	// Groups are named by their first entry. The most similar pairs are
+	// joined first, and two groups are joined only if all the pairs of
+	// their entries are probable duplicates.
+	group := make([]int, len(entries))
+	members := make(map[int][]int)
+	for i := range entries {
+		group[i] = i
+		members[i] = []int{i}
+	}
+	groupScores := make(map[int]float64)
+	for _, p := range pairs {
+		gi, gj := group[p.i], group[p.j]
+		if gi == gj {
+			continue
+		}
+		score, linked := p.score, true
+		for _, a := range members[gi] {
+			for _, b := range members[gj] {
+				if a > b {
+					a, b = b, a
+				}
+				s, ok := scores[[2]int{a, b}]
+				if !ok {
+					linked = false
+					break
+				}
+				if s < score {
+					score = s
+				}
+			}
+			if !linked {
+				break
+			}
+		}
+		if !linked {
+			continue
+		}
+
+		if gj < gi {
+			gi, gj = gj, gi
+		}
+		for _, b := range members[gj] {
+			group[b] = gi
+		}
+		members[gi] = append(members[gi], members[gj]...)
+		delete(members, gj)
+		for _, g := range []int{gi, gj} {
+			if v, ok := groupScores[g]; ok && v < score {
+				score = v
+			}
+		}
+		delete(groupScores, gj)
+		groupScores[gi] = score
+	}
+
+	var ds []Duplicates
+	for g, score := range groupScores {
+		var es []*Entry
+		for _, i := range members[g] {
+			es = append(es, entries[i])
+		}
+		sort.Slice(es, func(i, j int) bool {
+			return es[i].GetKey() < es[j].GetKey()
+		})
+		ds = append(ds, Duplicates{Entries: es, Score: score})
+	}
+	sort.SliceStable(ds, func(i, j int) bool {
+		if ds[i].Score != ds[j].Score {
+			return ds[i].Score > ds[j].Score
+		}
+		return ds[i].Entries[0].GetKey() < ds[j].Entries[0].GetKey()
+	})
+
+	return ds
+}
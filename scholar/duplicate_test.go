@@ -0,0 +1,157 @@
+package scholar
+
+import (
+	"testing"
+)
+
+func mockDuplicate(key, title, author, date, doi string) *Entry {
+	e, _ := NewEntry("article")
+	e.Key = key
+	e.Required["title"] = title
+	e.Required["author"] = author
+	e.Required["date"] = date
+	e.Optional["doi"] = doi
+	return e
+}
+
+func TestSimilarity(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	a := mockDuplicate("a", "On the Electrodynamics of Moving Bodies", "Einstein, Albert", "1905", "")
+
+	tests := []struct {
+		name string
+		b    *Entry
+		min  float64
+		max  float64
+	}{
+		{"same doi", mockDuplicate("b", "Another Title", "Other, Name", "2000", "10.1002/andp.19053221004"), 1, 1},
+		{"fuzzy title", mockDuplicate("b", "On the electrodynamics of moving bodies.", "Einstein, A.", "1905", ""), 0.9, 1},
+		{"typo", mockDuplicate("b", "On the Electrodynamcs of Moving Body", "Einstein, Albert", "1906", ""), 0.75, 0.95},
+		{"different work", mockDuplicate("b", "A Theory of Justice", "Rawls, John", "1971", ""), 0, 0.3},
+	}
+
+	a.Optional["doi"] = "https://doi.org/10.1002/ANDP.19053221004"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(a, tt.b); got < tt.min || got > tt.max {
+				t.Errorf("got %.2f, want between %.2f and %.2f", got, tt.min, tt.max)
+			}
+		})
+	}
+
+	t.Run("editions", func(t *testing.T) {
+		first := mockDuplicate("a", "Introduction to Algorithms", "Cormen, Thomas H.", "1990", "")
+		third := mockDuplicate("b", "Introduction to Algorithms", "Cormen, Thomas H.", "2009", "")
+		if got := Similarity(first, third); got > 0.5 {
+			t.Errorf("years apart: got %.2f, want at most 0.50", got)
+		}
+
+		second := mockDuplicate("c", "Introduction to Algorithms", "Cormen, Thomas H.", "2009", "")
+		second.Optional["edition"] = "2nd"
+		third.Optional["edition"] = "3"
+		if got := Similarity(second, third); got > 0.5 {
+			t.Errorf("editions: got %.2f, want at most 0.50", got)
+		}
+
+		second.Optional["edition"] = "3rd ed."
+		second.Optional["volume"] = "1"
+		third.Optional["volume"] = "2"
+		if got := Similarity(second, third); got > 0.5 {
+			t.Errorf("volumes: got %.2f, want at most 0.50", got)
+		}
+
+		third.Optional["volume"] = "1"
+		if got := Similarity(second, third); got < 0.9 {
+			t.Errorf("same edition: got %.2f, want at least 0.90", got)
+		}
+	})
+
+	t.Run("different doi", func(t *testing.T) {
+		b := mockDuplicate("b", "On the Electrodynamics of Moving Bodies", "Einstein, Albert", "1905", "10.1000/other")
+		if got := Similarity(a, b); got >= 0.9 {
+			t.Errorf("got %.2f, want less than 0.9", got)
+		}
+	})
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want string
+	}{
+		{"0-306-40615-2", "9780306406157"},
+		{"978-0-306-40615-7", "9780306406157"},
+		{"0-306-40615-3", "0306406153"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeISBN(tt.isbn); got != tt.want {
+			t.Errorf("normalizeISBN(%q): got %q, want %q", tt.isbn, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []*Entry{
+		mockDuplicate("einstein1905", "On the Electrodynamics of Moving Bodies", "Einstein, Albert", "1905", ""),
+		mockDuplicate("rawls1971", "A Theory of Justice", "Rawls, John", "1971", ""),
+		mockDuplicate("einstein1905a", "On the electrodynamics of moving bodies", "Einstein, A.", "1905", ""),
+		mockDuplicate("rawls1971a", "A Theory of Justice", "Rawls, J.", "1971", "10.1000/justice"),
+		mockDuplicate("einstein1905b", "Zur Elektrodynamik bewegter Körper", "Einstein, Albert", "1905", "10.1002/andp.19053221004"),
+		mockDuplicate("einstein1905c", "Zur Elektrodynamik", "Einstein, Albert", "1905", "10.1002/ANDP.19053221004"),
+	}
+
+	ds := FindDuplicates(entries, 0.8)
+	want := [][]string{
+		{"einstein1905", "einstein1905a"},
+		{"einstein1905b", "einstein1905c"},
+		{"rawls1971", "rawls1971a"},
+	}
+	if len(ds) != len(want) {
+		t.Fatalf("got %d groups, want %d: %v", len(ds), len(want), ds)
+	}
+	for i, d := range ds {
+		var keys []string
+		for _, e := range d.Entries {
+			keys = append(keys, e.GetKey())
+		}
+		if len(keys) != len(want[i]) || keys[0] != want[i][0] || keys[1] != want[i][1] {
+			t.Errorf("group %d: got %v, want %v", i, keys, want[i])
+		}
+		if i > 0 && d.Score > ds[i-1].Score {
+			t.Errorf("group %d is not sorted by score: %.2f > %.2f", i, d.Score, ds[i-1].Score)
+		}
+	}
+}
+
+func TestFindDuplicates_Editions(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	first := mockDuplicate("cormen2000", "Introduction to Algorithms", "Cormen, Thomas H.", "2000", "")
+	first.Optional["edition"] = "1"
+	second := mockDuplicate("cormen2010", "Introduction to Algorithms", "Cormen, Thomas H.", "2010", "")
+	second.Optional["edition"] = "2"
+	undated := mockDuplicate("cormen", "Introduction to Algorithms", "Cormen, Thomas H.", "", "")
+
+	entries := []*Entry{first, second, undated}
+	if s := Similarity(undated, first); s < 0.8 {
+		t.Fatalf("undated entry is not a duplicate of an edition: %.2f", s)
+	}
+
+	ds := FindDuplicates(entries, 0.8)
+	if len(ds) != 1 || len(ds[0].Entries) != 2 {
+		t.Fatalf("got %v, want one group of two entries", ds)
+	}
+	if a, b := ds[0].Entries[0], ds[0].Entries[1]; a != undated && b != undated {
+		t.Errorf("both editions are in the same group: %s, %s", a.GetKey(), b.GetKey())
+	}
+}
@@ -0,0 +1,496 @@
+package scholar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// enText is the text of an EndNote XML element. EndNote can split the text of
+// an element in several <style> elements, which are joined when read.
+type enText string
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (t *enText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	b := new(strings.Builder)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			b.Write(tok)
+		case xml.EndElement:
+			if tok.Name == start.Name {
+				*t = enText(strings.TrimSpace(b.String()))
+				return nil
+			}
+		}
+	}
+}
+
+type enRefType struct {
+	Name  string `xml:"name,attr,omitempty"`
+	Value int    `xml:",chardata"`
+}
+
+// enAuthors, enKeywords, enDateList, and enURLList are lists of elements.
+// They are nil when empty, so that the parent element is not written.
+type enAuthors struct {
+	Author []enText `xml:"author"`
+}
+
+type enKeywords struct {
+	Keyword []enText `xml:"keyword"`
+}
+
+type enDateList struct {
+	Date []enText `xml:"date"`
+}
+
+type enURLList struct {
+	URL []enText `xml:"url"`
+}
+
+type enContributors struct {
+	Authors    *enAuthors `xml:"authors,omitempty"`
+	Secondary  *enAuthors `xml:"secondary-authors,omitempty"`
+	Translated *enAuthors `xml:"translated-authors,omitempty"`
+}
+
+type enTitles struct {
+	Title     enText `xml:"title,omitempty"`
+	Secondary enText `xml:"secondary-title,omitempty"`
+	Tertiary  enText `xml:"tertiary-title,omitempty"`
+	Short     enText `xml:"short-title,omitempty"`
+}
+
+type enPeriodical struct {
+	FullTitle enText `xml:"full-title,omitempty"`
+}
+
+type enDates struct {
+	Year     enText      `xml:"year,omitempty"`
+	PubDates *enDateList `xml:"pub-dates,omitempty"`
+}
+
+type enURLs struct {
+	Related *enURLList `xml:"related-urls,omitempty"`
+	PDF     *enURLList `xml:"pdf-urls,omitempty"`
+}
+
+type enRecord struct {
+	XMLName      xml.Name        `xml:"record"`
+	RefType      enRefType       `xml:"ref-type"`
+	Contributors *enContributors `xml:"contributors,omitempty"`
+	Titles       *enTitles       `xml:"titles,omitempty"`
+	Periodical   *enPeriodical   `xml:"periodical,omitempty"`
+	Pages        enText          `xml:"pages,omitempty"`
+	Volume       enText          `xml:"volume,omitempty"`
+	Number       enText          `xml:"number,omitempty"`
+	Edition      enText          `xml:"edition,omitempty"`
+	Keywords     *enKeywords     `xml:"keywords,omitempty"`
+	Dates        *enDates        `xml:"dates,omitempty"`
+	Publisher    enText          `xml:"publisher,omitempty"`
+	PubLocation  enText          `xml:"pub-location,omitempty"`
+	ISBN         enText          `xml:"isbn,omitempty"`
+	DOI          enText          `xml:"electronic-resource-num,omitempty"`
+	Abstract     enText          `xml:"abstract,omitempty"`
+	Notes        enText          `xml:"notes,omitempty"`
+	Label        enText          `xml:"label,omitempty"`
+	WorkType     enText          `xml:"work-type,omitempty"`
+	AccessDate   enText          `xml:"access-date,omitempty"`
+	Language     enText          `xml:"language,omitempty"`
+	URLs         *enURLs         `xml:"urls,omitempty"`
+}
+
+type exEndNote struct {
+	types map[string]enRefType
+}
+
+// enName returns the name in the "Last, First" EndNote format. Names enclosed
+// in braces are written as corporate names, which end with a comma.
+func enName(n Name) enText {
+	if n.First == "" && n.Von == "" && n.Jr == "" && strings.HasPrefix(n.Last, "{") {
+		return enText(unbrace(n.Last) + ",")
+	}
+	return enText(unbrace(n.String()))
+}
+
+func enNames(value string) *enAuthors {
+	var names []enText
+	for _, n := range ParseNames(value) {
+		names = append(names, enName(n))
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return &enAuthors{Author: names}
+}
+
+// record returns the EndNote record of the entry. Fields without an EndNote
+// element are skipped.
+func (ex *exEndNote) record(e *Entry) enRecord {
+	get := func(field string) enText {
+		return enText(strings.TrimSpace(e.field(field)))
+	}
+
+	rec := enRecord{RefType: ex.types["misc"]}
+	if t, ok := ex.types[e.Type]; ok {
+		rec.RefType = t
+	}
+	rec.Label = enText(e.GetKey())
+
+	c := &enContributors{
+		Authors:    enNames(e.field("author")),
+		Secondary:  enNames(e.field("editor")),
+		Translated: enNames(e.field("translator")),
+	}
+	if *c != (enContributors{}) {
+		rec.Contributors = c
+	}
+
+	t := &enTitles{
+		Title:    get("title"),
+		Tertiary: get("series"),
+		Short:    get("shorttitle"),
+	}
+	if v := get("journaltitle"); v != "" {
+		t.Secondary = v
+		rec.Periodical = &enPeriodical{FullTitle: v}
+	} else {
+		t.Secondary = get("booktitle")
+	}
+	if *t != (enTitles{}) {
+		rec.Titles = t
+	}
+
+	rec.Pages = get("pages")
+	if ranges, err := ParseRanges(string(rec.Pages)); err == nil {
+		s := make([]string, len(ranges))
+		for i, r := range ranges {
+			s[i] = strings.Replace(r.String(), "--", "-", 1)
+		}
+		rec.Pages = enText(strings.Join(s, ", "))
+	}
+	rec.Volume = get("volume")
+	rec.Number = get("number")
+	rec.Edition = get("edition")
+	for _, kw := range strings.Split(e.field("keywords"), ",") {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			if rec.Keywords == nil {
+				rec.Keywords = &enKeywords{}
+			}
+			rec.Keywords.Keyword = append(rec.Keywords.Keyword, enText(kw))
+		}
+	}
+
+	if v := get("date"); v != "" {
+		rec.Dates = &enDates{Year: enText(e.Year()), PubDates: &enDateList{[]enText{v}}}
+	}
+	rec.AccessDate = get("urldate")
+
+	rec.Publisher = get("publisher")
+	if rec.Publisher == "" {
+		rec.Publisher = get("institution")
+	}
+	rec.PubLocation = get("location")
+	rec.ISBN = get("isbn")
+	if rec.ISBN == "" {
+		rec.ISBN = get("issn")
+	}
+	rec.DOI = get("doi")
+	rec.Abstract = get("abstract")
+	rec.Notes = get("note")
+	rec.WorkType = get("type")
+	rec.Language = get("language")
+
+	u := &enURLs{}
+	if v := get("url"); v != "" {
+		u.Related = &enURLList{[]enText{v}}
+	}
+	for _, a := range e.Files {
+		if u.PDF == nil {
+			u.PDF = &enURLList{}
+		}
+		u.PDF.URL = append(u.PDF.URL, enText(enFileURL(a.Path)))
+	}
+	if *u != (enURLs{}) {
+		rec.URLs = u
+	}
+
+	return rec
+}
+
+// enFileURL returns the file URL of an absolute path. Relative paths are
+// returned unchanged.
+func enFileURL(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		// Windows paths start with the drive letter
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// enFilePath returns the path of a file of pdf-urls. EndNote links the files
+// of its library with internal-pdf URLs, which are relative to the PDF folder
+// of the library.
+func enFilePath(s string) string {
+	s = strings.TrimSpace(s)
+	var p string
+	switch {
+	case strings.HasPrefix(s, "file://"):
+		u, err := url.Parse(s)
+		if err != nil {
+			return strings.TrimPrefix(s, "file://")
+		}
+		p = u.Path
+		if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+			// Windows paths start with the drive letter
+			p = p[1:]
+		}
+	case strings.HasPrefix(s, "internal-pdf://"):
+		p = strings.TrimPrefix(s, "internal-pdf://")
+		if u, err := url.PathUnescape(p); err == nil {
+			p = u
+		}
+	default:
+		return s
+	}
+	return filepath.FromSlash(p)
+}
+
+// Begin implements the Exporter interface. The entries are written as
+// an EndNote XML document.
+func (ex *exEndNote) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header+"<xml>\n  <records>")
+	return err
+}
+
+// Entry implements the Exporter interface.
+func (ex *exEndNote) Entry(w io.Writer, e *Entry, i int) error {
+	d, err := xml.MarshalIndent(ex.record(e), "    ", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\n%s", d)
+	return err
+}
+
+// End implements the Exporter interface.
+func (ex *exEndNote) End(w io.Writer) error {
+	_, err := io.WriteString(w, "\n  </records>\n</xml>\n")
+	return err
+}
+
+var endnote = &exEndNote{
+	types: map[string]enRefType{
+		"article":       {"Journal Article", 17},
+		"book":          {"Book", 6},
+		"mvbook":        {"Book", 6},
+		"inbook":        {"Book Section", 5},
+		"collection":    {"Edited Book", 28},
+		"incollection":  {"Book Section", 5},
+		"inproceedings": {"Conference Proceedings", 10},
+		"proceedings":   {"Conference Proceedings", 10},
+		"report":        {"Report", 27},
+		"manual":        {"Report", 27},
+		"thesis":        {"Thesis", 32},
+		"online":        {"Web Page", 12},
+		"patent":        {"Patent", 25},
+		"software":      {"Computer Program", 9},
+		"dataset":       {"Dataset", 59},
+		"unpublished":   {"Unpublished Work", 34},
+		"misc":          {"Generic", 13},
+	},
+}
+
+type imEndNote struct {
+	types map[int]string
+}
+
+// newEndNoteParser returns an EndNote XML importer that reverses the mapping
+// of ex. Reference types in extra are added to the mapping, but do not
+// override it.
+func newEndNoteParser(ex *exEndNote, extra map[int]string) *imEndNote {
+	im := &imEndNote{types: make(map[int]string)}
+	for _, t := range []string{"article", "book", "inbook", "collection", "inproceedings", "report", "thesis", "online", "patent", "software", "dataset", "unpublished", "misc"} {
+		im.types[ex.types[t].Value] = t
+	}
+	for ref, t := range extra {
+		if _, ok := im.types[ref]; !ok {
+			im.types[ref] = t
+		}
+	}
+	return im
+}
+
+// read parses the records of an EndNote XML file. Records of a type that is
+// not loaded are read as "misc" entries.
+func (im *imEndNote) read(r io.Reader) ([]*Entry, error) {
+	d := xml.NewDecoder(r)
+
+	var entries []*Entry
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			if serr, ok := err.(*xml.SyntaxError); ok {
+				return nil, getError("Import", ErrParse, nil).
+					info(fmt.Sprintf("line %d: %s", serr.Line, serr.Msg))
+			}
+			return nil, getError("Import", ErrParse, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var rec enRecord
+		if err := d.DecodeElement(&rec, &start); err != nil {
+			if serr, ok := err.(*xml.SyntaxError); ok {
+				return nil, getError("Import", ErrParse, nil).
+					info(fmt.Sprintf("line %d: %s", serr.Line, serr.Msg))
+			}
+			return nil, getError("Import", ErrParse, err)
+		}
+		e, err := im.entry(rec)
+		if err != nil {
+			return nil, getError("Import", errNotDefined, err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+func (im *imEndNote) entry(rec enRecord) (*Entry, error) {
+	e, err := NewEntry(im.types[rec.RefType.Value])
+	if IsError(ErrTypeNotFound, err) {
+		e, err = NewEntry("misc")
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Key = string(rec.Label)
+
+	et := EntryTypes[e.Type]
+	has := func(field string) bool {
+		return et != nil && et.Field(field) != nil
+	}
+	set := func(field string, value enText) {
+		if v := strings.TrimSpace(string(value)); v != "" {
+			e.Set(field, e.formatField(field, v))
+		}
+	}
+	names := func(field string, list *enAuthors) {
+		if list == nil {
+			return
+		}
+		var s []string
+		for _, n := range list.Author {
+			if name := strings.TrimSpace(string(n)); strings.HasSuffix(name, ",") {
+				s = append(s, "{"+strings.TrimSuffix(name, ",")+"}")
+			} else if name != "" {
+				s = append(s, ParseName(name).String())
+			}
+		}
+		set(field, enText(strings.Join(s, " and ")))
+	}
+
+	if c := rec.Contributors; c != nil {
+		names("author", c.Authors)
+		names("editor", c.Secondary)
+		names("translator", c.Translated)
+	}
+
+	if t := rec.Titles; t != nil {
+		set("title", t.Title)
+		set("series", t.Tertiary)
+		set("shorttitle", t.Short)
+		if t.Secondary != "" {
+			if !has("journaltitle") && has("booktitle") {
+				set("booktitle", t.Secondary)
+			} else {
+				set("journaltitle", t.Secondary)
+			}
+		}
+	}
+	if p := rec.Periodical; p != nil && e.field("journaltitle") == "" {
+		set("journaltitle", p.FullTitle)
+	}
+
+	set("pages", rec.Pages)
+	set("volume", rec.Volume)
+	set("number", rec.Number)
+	set("edition", rec.Edition)
+	if rec.Keywords != nil {
+		var kws []string
+		for _, kw := range rec.Keywords.Keyword {
+			kws = append(kws, string(kw))
+		}
+		set("keywords", enText(strings.Join(kws, ", ")))
+	}
+
+	if d := rec.Dates; d != nil {
+		year := strings.TrimSpace(string(d.Year))
+		var date string
+		if d.PubDates != nil && len(d.PubDates.Date) > 0 {
+			date = strings.TrimSpace(string(d.PubDates.Date[0]))
+		}
+		switch {
+		case date != "" && checkDate(date) == nil:
+			set("date", enText(date))
+		case year != "":
+			set("date", enText(bibDate(year, date)))
+		}
+	}
+	set("urldate", rec.AccessDate)
+
+	if !has("publisher") && has("institution") {
+		set("institution", rec.Publisher)
+	} else {
+		set("publisher", rec.Publisher)
+	}
+	set("location", rec.PubLocation)
+	if checkISSN(string(rec.ISBN)) == nil {
+		set("issn", rec.ISBN)
+	} else {
+		set("isbn", rec.ISBN)
+	}
+	set("doi", rec.DOI)
+	set("abstract", rec.Abstract)
+	set("note", rec.Notes)
+	set("type", rec.WorkType)
+	set("language", rec.Language)
+
+	if u := rec.URLs; u != nil {
+		if u.Related != nil && len(u.Related.URL) > 0 {
+			set("url", u.Related.URL[0])
+		}
+		var pdf []enText
+		if u.PDF != nil {
+			pdf = u.PDF.URL
+		}
+		for _, p := range pdf {
+			if path := enFilePath(string(p)); path != "" {
+				e.Attach(Attachment{Path: path})
+			}
+		}
+	}
+
+	return e, nil
+}
+
+var endnoteParser = newEndNoteParser(endnote, map[int]string{
+	3:  "inproceedings",
+	47: "inproceedings",
+})
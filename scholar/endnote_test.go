@@ -0,0 +1,151 @@
+package scholar
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImport_EndNote(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<xml><records>
+<record>
+  <ref-type name="Journal Article">17</ref-type>
+  <contributors><authors>
+    <author><style face="normal" font="default" size="100%">Einstein, </style><style face="bold">Albert</style></author>
+    <author>World Health Organization,</author>
+  </authors></contributors>
+  <titles>
+    <title><style face="normal">Zur Elektrodynamik bewegter Körper</style></title>
+    <secondary-title>Annalen der Physik</secondary-title>
+  </titles>
+  <pages>891-921</pages>
+  <keywords><keyword>relativity</keyword><keyword>physics</keyword></keywords>
+  <dates><year>1905</year><pub-dates><date>Jun 30</date></pub-dates></dates>
+  <isbn>0003-3804</isbn>
+  <electronic-resource-num>10.1002/andp.19053221004</electronic-resource-num>
+  <label>einstein1905</label>
+  <urls>
+    <related-urls><url>https://example.com/paper</url></related-urls>
+    <pdf-urls><url>file:///papers/einstein%201905.pdf</url><url>internal-pdf://0123456789/notes.pdf</url></pdf-urls>
+  </urls>
+</record>
+<record><ref-type name="Map">20</ref-type><titles><title>A Map</title></titles></record>
+</records></xml>`
+
+	entries, err := Import(strings.NewReader(in), "endnote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Import() returned %d entries, want 2", len(entries))
+	}
+
+	e := entries[0]
+	if e.Type != "article" || e.Key != "einstein1905" {
+		t.Errorf("got @%s{%s}, want @article{einstein1905}", e.Type, e.Key)
+	}
+	want := map[string]string{
+		"author":       "Einstein, Albert and {World Health Organization}",
+		"title":        "Zur Elektrodynamik bewegter Körper",
+		"journaltitle": "Annalen der Physik",
+		"pages":        "891--921",
+		"keywords":     "relativity, physics",
+		"date":         "1905-06",
+		"issn":         "0003-3804",
+		"doi":          "10.1002/andp.19053221004",
+		"url":          "https://example.com/paper",
+	}
+	for field, value := range want {
+		if got := e.field(field); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+	if len(e.Files) != 2 || e.Files[0].Path != filepath.FromSlash("/papers/einstein 1905.pdf") || e.Files[1].Path != filepath.FromSlash("0123456789/notes.pdf") {
+		t.Errorf("Files = %v, want [/papers/einstein 1905.pdf 0123456789/notes.pdf]", e.Files)
+	}
+	if entries[1].Type != "misc" {
+		t.Errorf("Type = %q, want misc", entries[1].Type)
+	}
+
+	_, err = Import(strings.NewReader("<xml><records>\n<record>\n</records></xml>"), "endnote")
+	if !IsError(ErrParse, err) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Import() error = %v, want ErrParse at line 3", err)
+	}
+}
+
+func TestExEndNote_RoundTrip(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["author"] = "von Neumann, John and {World Health Organization}"
+	entry.Required["date"] = "2006-03-01"
+	entry.Optional["doi"] = "10.1000/182"
+	entry.Optional["pages"] = "1--10"
+	entry.Attach(Attachment{Path: "paper.pdf"})
+
+	out, err := ExportList([]*Entry{entry}, "endnote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Import(strings.NewReader(out), "endnote")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	got := entries[0]
+	if got.Type != entry.Type || got.Key != entry.Key {
+		t.Errorf("got @%s{%s}, want @%s{%s}", got.Type, got.Key, entry.Type, entry.Key)
+	}
+	for _, field := range []string{"author", "title", "journaltitle", "date", "doi", "pages"} {
+		if got.field(field) != entry.field(field) {
+			t.Errorf("%s = %q, want %q", field, got.field(field), entry.field(field))
+		}
+	}
+	if len(got.Files) != 1 || got.Files[0].Path != "paper.pdf" {
+		t.Errorf("Files = %v, want [paper.pdf]", got.Files)
+	}
+	t.Run("absolute path", func(t *testing.T) {
+		path, err := filepath.Abs(filepath.Join("papers", "my paper.pdf"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := *entry
+		e.Files = []Attachment{{Path: path}}
+
+		out, err := ExportList([]*Entry{&e}, "endnote")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "<url>file://"; !strings.Contains(out, want) || !strings.Contains(out, "my%20paper.pdf</url>") {
+			t.Errorf("ExportList() = %s, want a file URL", out)
+		}
+		entries, err := Import(strings.NewReader(out), "endnote")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files := entries[0].Files; len(files) != 1 || files[0].Path != path {
+			t.Errorf("Files = %v, want [%s]", files, path)
+		}
+	})
+	t.Run("no periodical", func(t *testing.T) {
+		book, err := NewEntry("book")
+		if err != nil {
+			t.Fatal(err)
+		}
+		book.Required["title"] = "The Book"
+
+		out, err := ExportList([]*Entry{book}, "endnote")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(out, "<periodical") {
+			t.Errorf("ExportList() has an empty periodical:\n%s", out)
+		}
+	})
+}
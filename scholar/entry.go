@@ -7,30 +7,82 @@ import (
 	"os"
 	"sort"
 	"strings"
-	"time"
 )
 
 // EntryType defines how each entry will be formatted. Each entry has
 // a TYPE of entry, a short DESCRIPTION, REQUIRED fields, and
 // OPTIONAL fields according to BibLaTex documentation.
+//
+// An entry type can EXTEND another entry type to inherit its description,
+// fields, and aliases. ALIASES map alternative field names to the fields of
+// the entry type (for example, journal -> journaltitle).
 type EntryType struct {
 	Type        string
 	Description string            `yaml:"desc"`
-	Required    map[string]string `yaml:"req"`
-	Optional    map[string]string `yaml:"opt"`
+	Extends     string            `yaml:"extends"`
+	Required    map[string]*Field `yaml:"req"`
+	Optional    map[string]*Field `yaml:"opt"`
+	Aliases     map[string]string `yaml:"aliases"`
+}
+
+// Canonical returns the name of the field aliased by field. If field is not
+// an alias, it is returned as it is.
+func (e *EntryType) Canonical(field string) string {
+	if to, ok := e.Aliases[field]; ok {
+		return to
+	}
+	return field
+}
+
+// inherit copies the description, fields, and aliases of parent that are not
+// defined by the entry type.
+func (e *EntryType) inherit(parent *EntryType) {
+	if e.Description == "" {
+		e.Description = parent.Description
+	}
+	if e.Required == nil {
+		e.Required = make(map[string]*Field)
+	}
+	if e.Optional == nil {
+		e.Optional = make(map[string]*Field)
+	}
+	if e.Aliases == nil {
+		e.Aliases = make(map[string]string)
+	}
+
+	defined := func(field string) bool {
+		_, req := e.Required[field]
+		_, opt := e.Optional[field]
+		return req || opt
+	}
+	for field, desc := range parent.Required {
+		if !defined(field) {
+			e.Required[field] = desc
+		}
+	}
+	for field, desc := range parent.Optional {
+		if !defined(field) {
+			e.Optional[field] = desc
+		}
+	}
+	for alias, field := range parent.Aliases {
+		if _, ok := e.Aliases[alias]; !ok {
+			e.Aliases[alias] = field
+		}
+	}
 }
 
 func (e *EntryType) get() *Entry {
 	var c Entry
 	c.Type = e.Type
 	c.Required = make(map[string]string)
-	for k := range e.Required {
-		c.Required[k] = ""
+	for k, f := range e.Required {
+		c.Required[k] = f.Default
 	}
 
 	c.Optional = make(map[string]string)
-	for k := range e.Optional {
-		c.Optional[k] = ""
+	for k, f := range e.Optional {
+		c.Optional[k] = f.Default
 	}
 
 	return &c
@@ -50,7 +102,7 @@ func (e *EntryType) info(level int) string {
 		}
 		sort.Strings(fields)
 		for _, field := range fields {
-			b.WriteString(fmt.Sprintf("  %s -> %s\n", field, e.Required[field]))
+			b.WriteString(fmt.Sprintf("  %s -> %s\n", field, e.Required[field].Description))
 		}
 
 		if level > 1 {
@@ -62,7 +114,7 @@ func (e *EntryType) info(level int) string {
 			}
 			sort.Strings(fields)
 			for _, field := range fields {
-				b.WriteString(fmt.Sprintf("     (%v) -> %v\n", field, e.Optional[field]))
+				b.WriteString(fmt.Sprintf("     (%v) -> %v\n", field, e.Optional[field].Description))
 			}
 		}
 	}
@@ -81,31 +133,26 @@ type Entry struct {
 	Key      string            `yaml:"key"`
 	Required map[string]string `yaml:"req"`
 	Optional map[string]string `yaml:"opt"`
-	File     string            `yaml:"file"`
+	Files    []Attachment      `yaml:"files,omitempty"`
 	Info     os.FileInfo       `yaml:"-"`
 }
 
-// Attach attaches a file path to the entry.
-func (e *Entry) Attach(file string) {
-	e.File = file
-}
-
-// Check checks if the fields are formatted correctly.
-// [Currently not useful]
-func (e *Entry) Check() error {
-	date := e.Required["date"]
-	_, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		_, err = time.Parse("2006-01", date)
-		if err != nil {
-			_, err = time.Parse("2006", date)
-			if err != nil {
-				return fmt.Errorf("invalid date format (date %s). Please use YYYY[-MM[-DD]]", date)
-			}
-		}
+// Set sets the value of a field of the entry. If the field is an alias
+// defined by the entry type, the value is set to the aliased field. The field
+// is set as required if the entry has it as a required field, otherwise it is
+// set as optional.
+func (e *Entry) Set(field, value string) {
+	if et, ok := EntryTypes[e.Type]; ok {
+		field = et.Canonical(field)
 	}
-
-	return nil
+	if _, ok := e.Required[field]; ok {
+		e.Required[field] = value
+		return
+	}
+	if e.Optional == nil {
+		e.Optional = make(map[string]string)
+	}
+	e.Optional[field] = value
 }
 
 // Year returns the year of the entry.
@@ -113,17 +160,22 @@ func (e *Entry) Year() string {
 	return fmt.Sprintf("%.4s", e.Required["date"])
 }
 
-// FirstAuthorLast return the lastname of the first author of the entry.
+// FirstAuthorLast return the lastname of the first author of the entry. If
+// the entry has no author, the lastname of the first editor is returned.
 func (e *Entry) FirstAuthorLast() string {
-	return strings.Split(e.Required["author"], ",")[0]
+	names := e.authorsOrEditors()
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0].LastName()
 }
 
 // GetKey return the key of the entry. If there is no key, a new key is
-// generated with lastnameYEAR format.
+// generated using KeyFormat, which defaults to lastnameYEAR format.
 // For example: einstein1922
 func (e *Entry) GetKey() string {
 	if e.Key == "" {
-		e.Key = fmt.Sprintf("%s%s", strings.ToLower(e.FirstAuthorLast()), e.Year())
+		e.Key = KeyFormat.Key(e)
 	}
 	return e.Key
 }
@@ -140,23 +192,43 @@ func (e *Entry) GetKey() string {
 // Any field that was Required in the original entry, but it is not on the
 // output entry type, is converted to an Optional field. This ensures back and
 // forth conversion of the same entry without losing information.
+//
+// Fields that are aliases in the output entry type are renamed to the fields
+// they alias (for example, journal -> journaltitle).
 func Convert(e *Entry, entryType string) (*Entry, error) {
 	to, err := NewEntry(entryType)
 	if err != nil {
 		return to, getError("Convert", ErrTypeNotFound, err)
 	}
 	to.Key = e.Key
-	to.Attach(e.File)
+	to.Attach(e.Files...)
+
+	// Flatten the fields of the old entry using the field names of the
+	// new entry type. Canonical names take precedence over aliases, and
+	// required fields take precedence over optional fields, but empty
+	// values never replace a value.
+	et := EntryTypes[entryType]
+	fields := make(map[string]string)
+	for _, canonical := range []bool{false, true} {
+		for _, from := range []map[string]string{e.Optional, e.Required} {
+			for field, value := range from {
+				to := et.Canonical(field)
+				if (to == field) != canonical {
+					continue
+				}
+				if _, ok := fields[to]; value != "" || !ok {
+					fields[to] = value
+				}
+			}
+		}
+	}
 
 	seen := make(map[string]bool)
 	var convertError error
 
-	// Check for the new required fields in the required and
-	// optional fields of the old entry
+	// Check for the new required fields in the fields of the old entry
 	for field := range to.Required {
-		if value, ok := e.Required[field]; ok {
-			to.Required[field] = value
-		} else if value, ok := e.Optional[field]; ok {
+		if value, ok := fields[field]; ok {
 			to.Required[field] = value
 		} else {
 			convertError = getError("Convert", ErrFieldNotFound, convertError).
@@ -167,25 +239,15 @@ func Convert(e *Entry, entryType string) (*Entry, error) {
 		seen[field] = true
 	}
 
-	// Dump of remaining required fields of the old entry to
-	// optional fields of the new entry
-	for field, value := range e.Required {
-		if seen[field] {
-			continue
-		}
-		if value != "" {
-			to.Optional[field] = value
-		}
-		seen[field] = true
-	}
-	for field, value := range e.Optional {
+	// Dump of remaining fields of the old entry to optional fields of the
+	// new entry
+	for field, value := range fields {
 		if seen[field] {
 			continue
 		}
 		if value != "" {
 			to.Optional[field] = value
 		}
-		seen[field] = true
 	}
 
 	return to, convertError
@@ -194,55 +256,16 @@ func Convert(e *Entry, entryType string) (*Entry, error) {
 // Bib returns a string with all the information of the entry
 // in BibLaTex format.
 func (e *Entry) Bib() string {
-	bib := new(strings.Builder)
-
-	// @type{key,
-	fmt.Fprintf(bib, "@%s{%s", e.Type, e.GetKey())
-
-	// field = {value},
-	fields := make([]string, len(e.Required))
-	i := 0
-	for field := range e.Required {
-		fields[i] = field
-		i++
-	}
-	sort.Strings(fields)
-	for _, field := range fields {
-		if value := e.Required[field]; value != "" {
-			fmt.Fprintf(bib, ",\n  %s = {%s}", field, value)
-		}
-	}
-
-	fields = make([]string, len(e.Optional))
-	i = 0
-	for field := range e.Optional {
-		fields[i] = field
-		i++
-	}
-	sort.Strings(fields)
-	for _, field := range fields {
-		if value := e.Optional[field]; value != "" && field != "abstract" {
-			fmt.Fprintf(bib, ",\n  %s = {%s}", field, value)
-		}
-	}
-	if value, ok := e.Optional["abstract"]; ok {
-		fmt.Fprintf(bib, ",\n  %s = {%s}", "abstract", value)
-	}
-	if file := e.File; file != "" {
-		fmt.Fprintf(bib, ",\n  %s = {%s}", "file", file)
-	}
-
-	bib.WriteString("\n}")
-
-	return bib.String()
+	b := new(strings.Builder)
+	biblatex.Entry(b, e, 0)
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
 // Export returns a string with all the information of the entry in the given
-// format.
-func (e *Entry) Export(format string) string {
-	ex := getExporter(format)
-
-	return ex.export(e)
+// format, as a list of a single entry (see ExportList). If the format is not
+// registered, it returns an ErrFormatNotFound error.
+func (e *Entry) Export(format string) (string, error) {
+	return ExportList([]*Entry{e}, format)
 }
 
 const bibTemplate = `@[[ .Type ]]{[[ .GetKey ]]
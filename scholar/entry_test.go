@@ -346,3 +346,156 @@ func BenchmarkEntry_bibT(b *testing.B) {
 		entry.bibT()
 	}
 }
+
+var mockInheritedTypes = []byte(`# Mock Entry Types with inheritance
+
+book:
+  desc: A single-volume book.
+  req:
+    author: Author(s) of the book.
+    title: Title of the book.
+    date: YYYY-MM-DD format.
+  opt:
+    editor: Editor(s) of the book.
+    publisher: Publisher of the book.
+  aliases:
+    address: location
+
+mvbook:
+  desc: A multi-volume @book.
+  extends: book
+  opt:
+    volumes: Number of volumes.
+
+inbook:
+  desc: A part of a book.
+  extends: book
+  req:
+    booktitle: Title of the book.
+  opt:
+    author: Author(s) of the book section.
+
+article:
+  desc: An article in a journal.
+  req:
+    author: Author(s) of the article.
+    title: Title of the article.
+    journaltitle: Title of the journal.
+    date: YYYY-MM-DD format.
+  aliases:
+    journal: journaltitle
+`)
+
+func TestLoadTypes_Extends(t *testing.T) {
+	err := loadTypes(mockInheritedTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		desc     string
+		required []string
+		optional []string
+	}{
+		{"mvbook", "A multi-volume @book.", []string{"author", "date", "title"}, []string{"editor", "publisher", "volumes"}},
+		{"inbook", "A part of a book.", []string{"booktitle", "date", "title"}, []string{"author", "editor", "publisher"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			et := EntryTypes[tt.name]
+			if et.Description != tt.desc {
+				t.Errorf("Description = %q, want %q", et.Description, tt.desc)
+			}
+			if len(et.Required) != len(tt.required) {
+				t.Errorf("Required = %v, want %v", et.Required, tt.required)
+			}
+			for _, field := range tt.required {
+				if _, ok := et.Required[field]; !ok {
+					t.Errorf("Required[%s] was not inherited", field)
+				}
+			}
+			if len(et.Optional) != len(tt.optional) {
+				t.Errorf("Optional = %v, want %v", et.Optional, tt.optional)
+			}
+			for _, field := range tt.optional {
+				if _, ok := et.Optional[field]; !ok {
+					t.Errorf("Optional[%s] was not inherited", field)
+				}
+			}
+			if got := et.Canonical("address"); got != "location" {
+				t.Errorf("Canonical(%q) = %q, want %q", "address", got, "location")
+			}
+		})
+	}
+
+	invalid := map[string][]byte{
+		"unknown parent": []byte("book:\n  extends: missing\n"),
+		"cycle":          []byte("a:\n  extends: b\nb:\n  extends: c\nc:\n  extends: a\n"),
+	}
+	for name, types := range invalid {
+		t.Run(name, func(t *testing.T) {
+			err := loadTypes(types)
+			if !IsError(ErrTypeNotFound, err) {
+				t.Fatal("error other than ErrTypeNotFound:", err)
+			}
+			t.Log("Expected error:\n", err)
+		})
+	}
+}
+
+func TestConvert_Aliases(t *testing.T) {
+	err := loadTypes(mockInheritedTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := NewEntry("book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book.Set("title", "The Title")
+	book.Set("address", "Tokyo")
+	book.Optional["journal"] = "The Journal"
+	if got := book.Optional["location"]; got != "Tokyo" {
+		t.Errorf("Set(%q) did not set the aliased field: got %q, want %q", "address", got, "Tokyo")
+	}
+
+	article, err := Convert(book, "article")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := article.Required["journaltitle"]; got != "The Journal" {
+		t.Errorf("Convert() did not resolve the alias: got %q, want %q", got, "The Journal")
+	}
+	if _, ok := article.Optional["journal"]; ok {
+		t.Errorf("Convert() kept the alias as an optional field")
+	}
+}
+
+func TestConvert_EmptyCanonical(t *testing.T) {
+	err := loadTypes(mockInheritedTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	article, err := NewEntry("article")
+	if err != nil {
+		t.Fatal(err)
+	}
+	article.Required["title"] = "The Title"
+	article.Required["journaltitle"] = ""
+	article.Optional["journal"] = "Nature"
+
+	got, err := Convert(article, "article")
+	if err != nil && !IsError(ErrFieldNotFound, err) {
+		t.Fatal(err)
+	}
+	if got.Required["journaltitle"] != "Nature" {
+		t.Errorf("empty field replaced the alias: got %q, want %q", got.Required["journaltitle"], "Nature")
+	}
+	if got.Required["title"] != "The Title" {
+		t.Errorf("title: got %q, want %q", got.Required["title"], "The Title")
+	}
+}
@@ -2,35 +2,54 @@ package scholar
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 )
 
-type tnfError struct {
-	requested string
-}
-
-func (e *tnfError) Error() string {
-	return fmt.Sprintf("not found: type %s", e.requested)
-}
-
-// TypeNotFoundError is deprecated.
-var TypeNotFoundError *tnfError
+// TypeNotFoundError is the kind of the errors returned when an entry type is
+// not found.
+//
+// Deprecated: use errors.Is(err, ErrTypeNotFound).
+var TypeNotFoundError = ErrTypeNotFound
 
 type errorOp string
 
-type errorType uint8
+// ErrorKind is the kind of an error returned by the package. Each kind is a
+// sentinel error that can be checked with errors.Is:
+//
+//	if errors.Is(err, scholar.ErrEntryNotFound) {
+//		// ...
+//	}
+type ErrorKind uint8
 
 const (
 	// ErrNotDefined represents a not defined error.
-	errNotDefined errorType = iota
+	errNotDefined ErrorKind = iota
 	// ErrTypeNotFound represents an entry type not found error.
 	ErrTypeNotFound
 	// ErrFieldNotFound represents a field not found error.
 	ErrFieldNotFound
+	// ErrEntryNotFound represents an entry not found error.
+	ErrEntryNotFound
+	// ErrLibraryNotFound represents a library not found error.
+	ErrLibraryNotFound
+	// ErrInvalidTemplate represents an invalid template error.
+	ErrInvalidTemplate
+	// ErrInvalidEntry represents an entry with invalid fields error.
+	ErrInvalidEntry
+	// ErrParse represents a syntax error in an imported file.
+	ErrParse
+	// ErrFormatNotFound represents an import or export format not found error.
+	ErrFormatNotFound
+	// ErrStyleNotFound represents a citation style not found error.
+	ErrStyleNotFound
+	// ErrIO represents an error reading or writing a file.
+	ErrIO
+	// ErrNetwork represents an error fetching data from a remote service.
+	ErrNetwork
 )
 
 // String implements the Stringer interface.
-func (e errorType) String() string {
+func (e ErrorKind) String() string {
 	switch e {
 	case errNotDefined:
 		return "undefined error"
@@ -38,15 +57,39 @@ func (e errorType) String() string {
 		return "entry type not found error"
 	case ErrFieldNotFound:
 		return "field not found error"
+	case ErrEntryNotFound:
+		return "entry not found error"
+	case ErrLibraryNotFound:
+		return "library not found error"
+	case ErrInvalidTemplate:
+		return "invalid template error"
+	case ErrInvalidEntry:
+		return "invalid entry error"
+	case ErrParse:
+		return "parse error"
+	case ErrFormatNotFound:
+		return "format not found error"
+	case ErrStyleNotFound:
+		return "style not found error"
+	case ErrIO:
+		return "input/output error"
+	case ErrNetwork:
+		return "network error"
 	}
 
 	return "unknown error"
 }
 
-// Err represents a custom error handler.
+// Error implements the error interface.
+func (e ErrorKind) Error() string {
+	return e.String()
+}
+
+// Err represents a custom error handler. Its kind and the error it wraps can
+// be checked with errors.Is and errors.As.
 type Err struct {
 	op    errorOp
-	eType errorType
+	eType ErrorKind
 	extra string
 	err   error
 }
@@ -88,13 +131,37 @@ func (e *Err) Error() string {
 	return b.String()
 }
 
+// Unwrap returns the error wrapped by e.
+func (e *Err) Unwrap() error {
+	return e.err
+}
+
+// Is checks if e is of the kind target.
+func (e *Err) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind != errNotDefined && e.eType == kind
+}
+
+// Kind returns the kind of the error, or the kind of the first error it wraps
+// that has one.
+func (e *Err) Kind() ErrorKind {
+	var err error = e
+	for err != nil {
+		if e, ok := err.(*Err); ok && e.eType != errNotDefined {
+			return e.eType
+		}
+		err = errors.Unwrap(err)
+	}
+	return errNotDefined
+}
+
 // info adds extra information to an error.
 func (e *Err) info(text string) *Err {
 	e.extra = text
 	return e
 }
 
-func getError(op errorOp, eType errorType, err error) *Err {
+func getError(op errorOp, eType ErrorKind, err error) *Err {
 	e := &Err{
 		op:    op,
 		eType: eType,
@@ -121,17 +188,8 @@ func getError(op errorOp, eType errorType, err error) *Err {
 	return e
 }
 
-// IsError checks if err is an error of the given type.
-func IsError(eType errorType, err error) bool {
-	e, ok := err.(*Err)
-	if !ok {
-		return false
-	}
-	if e.eType != errNotDefined {
-		return e.eType == eType
-	}
-	if e.err != nil {
-		return IsError(eType, e.err)
-	}
-	return false
+// IsError checks if err is an error of the given kind. It is the same as
+// errors.Is(err, eType).
+func IsError(eType ErrorKind, err error) bool {
+	return errors.Is(err, eType)
 }
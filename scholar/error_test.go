@@ -0,0 +1,67 @@
+package scholar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErr_Is(t *testing.T) {
+	_, err := NewEntry("unknown")
+	if !errors.Is(err, ErrTypeNotFound) {
+		t.Errorf("errors.Is(%v, ErrTypeNotFound) = false", err)
+	}
+	if !errors.Is(err, TypeNotFoundError) {
+		t.Errorf("errors.Is(%v, TypeNotFoundError) = false", err)
+	}
+	if errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("errors.Is(%v, ErrEntryNotFound) = true", err)
+	}
+
+	wrapped := getError("Outer", errNotDefined, getError("Inner", ErrParse, nil))
+	if !IsError(ErrParse, wrapped) || wrapped.Kind() != ErrParse {
+		t.Errorf("IsError(ErrParse, %v) = false, Kind() = %v", wrapped, wrapped.Kind())
+	}
+
+	other := fmt.Errorf("fetch: %w", getError("Get", ErrEntryNotFound, nil))
+	var e *Err
+	if !errors.As(other, &e) || e.Kind() != ErrEntryNotFound {
+		t.Errorf("errors.As(%v) = %v, want an ErrEntryNotFound error", other, e)
+	}
+}
+
+func TestErr_Unwrap(t *testing.T) {
+	_, err := LoadCSL(filepath.Join(os.TempDir(), "scholar-missing.csl"))
+	if !errors.Is(err, ErrIO) {
+		t.Errorf("errors.Is(%v, ErrIO) = false", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("errors.Is(%v, os.ErrNotExist) = false", err)
+	}
+	var perr *os.PathError
+	if !errors.As(err, &perr) {
+		t.Errorf("errors.As(%v, *os.PathError) = false", err)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	kinds := []ErrorKind{
+		ErrTypeNotFound, ErrFieldNotFound, ErrEntryNotFound, ErrLibraryNotFound,
+		ErrInvalidTemplate, ErrInvalidEntry, ErrParse, ErrFormatNotFound,
+		ErrStyleNotFound, ErrIO, ErrNetwork,
+	}
+	seen := make(map[string]bool)
+	for _, k := range kinds {
+		if msg := k.Error(); msg == "unknown error" || seen[msg] {
+			t.Errorf("%d.Error() = %q", k, msg)
+		}
+		seen[k.Error()] = true
+	}
+
+	err := fmt.Errorf("search: %w", ErrNetwork)
+	if !IsError(ErrNetwork, err) || IsError(ErrIO, err) {
+		t.Errorf("IsError(%v) does not match ErrNetwork only", err)
+	}
+}
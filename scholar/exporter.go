@@ -1,17 +1,201 @@
 package scholar
 
-type exporter interface {
-	export(*Entry) string
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exporter writes a list of entries in a reference format. Begin writes the
+// header of the list, Entry writes the i-th entry with its separator, and End
+// writes the footer.
+type Exporter interface {
+	Begin(w io.Writer) error
+	Entry(w io.Writer, e *Entry, i int) error
+	End(w io.Writer) error
+}
+
+// ListExporter is implemented by exporters that may need the whole list of
+// entries before writing it, such as a template with a header that counts the
+// entries. If NeedsList returns false, the entries are written one at a time
+// as with any other Exporter.
+type ListExporter interface {
+	Exporter
+	NeedsList() bool
+	WriteList(w io.Writer, entries []*Entry) error
+}
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[string]Exporter{
+		"bibtex":    bibtex,
+		"biblatex":  biblatex,
+		"ris":       ris,
+		"csljson":   csljson,
+		"endnote":   endnote,
+		"mods":      mods,
+		"hayagriva": hayagriva,
+	}
+)
+
+// RegisterExporter makes an export format available by name. If an exporter
+// with the same name is already registered, it is replaced. It panics if ex
+// is nil.
+func RegisterExporter(name string, ex Exporter) {
+	if ex == nil {
+		panic("scholar: RegisterExporter exporter is nil")
+	}
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[name] = ex
+}
+
+// Formats returns the sorted names of the registered export formats.
+func Formats() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func getExporter(format string) (Exporter, error) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	if ex, ok := exporters[format]; ok {
+		return ex, nil
+	}
+	return nil, getError("Export", ErrFormatNotFound, nil).
+		info(fmt.Sprintf("%q is not a registered format", format))
+}
+
+// ExportWriter writes entries to an io.Writer in an export format, as they
+// come. Only the entries of a ListExporter that needs the whole list are kept
+// until Close.
+type ExportWriter struct {
+	w  io.Writer
+	ex Exporter
+	n  int
+	// list holds the entries when the exporter needs the whole list.
+	list     []*Entry
+	needList bool
+}
+
+// ExportOption changes the exporter used by an ExportWriter.
+type ExportOption func(Exporter) (Exporter, error)
+
+// asciiExporter is implemented by exporters that can write Unicode letters as
+// LaTeX commands.
+type asciiExporter interface {
+	withASCII() Exporter
+}
+
+// ASCII makes the bibtex and biblatex formats write accented letters as LaTeX
+// commands, such as {\'e} for é, for programs that only read ASCII files.
+// Other formats return an ErrFormatNotFound error.
+func ASCII() ExportOption {
+	return func(ex Exporter) (Exporter, error) {
+		if ax, ok := ex.(asciiExporter); ok {
+			return ax.withASCII(), nil
+		}
+		return nil, getError("Export", ErrFormatNotFound, nil).
+			info("the format has no ASCII option")
+	}
+}
+
+// NewExportWriter returns a writer of entries in the given format. If the
+// format is not registered, it returns an ErrFormatNotFound error.
+func NewExportWriter(w io.Writer, format string, opts ...ExportOption) (*ExportWriter, error) {
+	ex, err := getExporter(format)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		if ex, err = opt(ex); err != nil {
+			return nil, getError("Export", errNotDefined, err).info(format)
+		}
+	}
+
+	ew := &ExportWriter{w: w, ex: ex}
+	if lx, ok := ex.(ListExporter); ok && lx.NeedsList() {
+		ew.needList = true
+		return ew, nil
+	}
+	if err := ex.Begin(w); err != nil {
+		return nil, exportError(err)
+	}
+	return ew, nil
+}
+
+// Write writes an entry.
+func (ew *ExportWriter) Write(e *Entry) error {
+	defer func() { ew.n++ }()
+
+	if ew.needList {
+		ew.list = append(ew.list, e)
+		return nil
+	}
+	if err := ew.ex.Entry(ew.w, e, ew.n); err != nil {
+		return exportError(err).info(e.GetKey())
+	}
+	return nil
+}
+
+// Close writes the end of the list. It does not close the underlying writer.
+func (ew *ExportWriter) Close() error {
+	var err error
+	if ew.needList {
+		err = ew.ex.(ListExporter).WriteList(ew.w, ew.list)
+		ew.list = nil
+	} else {
+		err = ew.ex.End(ew.w)
+	}
+	if err != nil {
+		return exportError(err)
+	}
+	return nil
+}
+
+// exportError wraps an error returned by an exporter. Errors other than
+// scholar errors come from the writer.
+func exportError(err error) *Err {
+	var e *Err
+	if errors.As(err, &e) {
+		return getError("Export", errNotDefined, err)
+	}
+	return getError("Export", ErrIO, err)
+}
+
+// WriteList writes the entries to w in the given format. If the format is not
+// registered, it returns an ErrFormatNotFound error.
+func WriteList(w io.Writer, entries []*Entry, format string) error {
+	ew, err := NewExportWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := ew.Write(e); err != nil {
+			return err
+		}
+	}
+	return ew.Close()
 }
 
-func getExporter(format string) exporter {
-	switch format {
-	case "bibtex":
-		return bibtex
-	case "biblatex":
-		return biblatex
-	case "ris":
-		return ris
+// ExportList returns the entries in the given format (see ExportWriter). If
+// the format is not registered, it returns an ErrFormatNotFound error.
+func ExportList(entries []*Entry, format string) (string, error) {
+	b := new(strings.Builder)
+	if err := WriteList(b, entries, format); err != nil {
+		return "", err
 	}
-	return biblatex
+	return b.String(), nil
 }
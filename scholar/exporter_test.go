@@ -0,0 +1,130 @@
+package scholar
+
+import (
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// exUpper writes the key of each entry in upper case.
+type exUpper struct{}
+
+func (ex *exUpper) Begin(w io.Writer) error { return nil }
+
+func (ex *exUpper) Entry(w io.Writer, e *Entry, i int) error {
+	_, err := io.WriteString(w, strings.ToUpper(e.GetKey())+"\n")
+	return err
+}
+
+func (ex *exUpper) End(w io.Writer) error { return nil }
+
+func TestRegisterExporter(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := entry.Export("upper"); !IsError(ErrFormatNotFound, err) {
+		t.Fatalf("Export(%q) error = %v, want ErrFormatNotFound", "upper", err)
+	}
+
+	RegisterExporter("upper", &exUpper{})
+	defer func() {
+		exportersMu.Lock()
+		delete(exporters, "upper")
+		exportersMu.Unlock()
+	}()
+
+	got, err := entry.Export("upper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ToUpper(entry.GetKey()) + "\n"; got != want {
+		t.Errorf("Export(%q) = %q, want %q", "upper", got, want)
+	}
+
+	want := "biblatex bibtex csljson endnote hayagriva mods ris upper"
+	if got := strings.Join(Formats(), " "); got != want {
+		t.Errorf("Formats() = %q, want %q", got, want)
+	}
+}
+
+// exKeys writes the keys of the entries as a comma separated list.
+type exKeys struct{}
+
+func (ex *exKeys) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, "<")
+	return err
+}
+
+func (ex *exKeys) Entry(w io.Writer, e *Entry, i int) error {
+	if i > 0 {
+		io.WriteString(w, ",")
+	}
+	_, err := io.WriteString(w, e.GetKey())
+	return err
+}
+
+func (ex *exKeys) End(w io.Writer) error {
+	_, err := io.WriteString(w, ">")
+	return err
+}
+
+// exCount writes the number of entries.
+type exCount struct{ exUpper }
+
+func (ex *exCount) NeedsList() bool { return true }
+
+func (ex *exCount) WriteList(w io.Writer, entries []*Entry) error {
+	_, err := io.WriteString(w, strconv.Itoa(len(entries)))
+	return err
+}
+
+func TestExportWriter(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Key = "other"
+
+	RegisterExporter("keys", &exKeys{})
+	RegisterExporter("count", &exCount{})
+	RegisterExporter("upper", &exUpper{})
+	defer func() {
+		exportersMu.Lock()
+		delete(exporters, "keys")
+		delete(exporters, "count")
+		delete(exporters, "upper")
+		exportersMu.Unlock()
+	}()
+
+	tests := []struct {
+		format  string
+		entries []*Entry
+		want    string
+	}{
+		{"keys", []*Entry{entry, other}, "<last2006,other>"},
+		{"keys", nil, "<>"},
+		{"count", []*Entry{entry, other}, "2"},
+		{"upper", []*Entry{entry, other}, "LAST2006\nOTHER\n"},
+	}
+	for _, tt := range tests {
+		b := new(strings.Builder)
+		if err := WriteList(b, tt.entries, tt.format); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("WriteList(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	if _, err := NewExportWriter(ioutil.Discard, "unknown"); !IsError(ErrFormatNotFound, err) {
+		t.Errorf("NewExportWriter() error = %v, want ErrFormatNotFound", err)
+	}
+}
@@ -0,0 +1,358 @@
+package scholar
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Datatype represents how the value of a field is formatted.
+type Datatype string
+
+const (
+	// DataLiteral is a plain text value.
+	DataLiteral Datatype = "literal"
+	// DataNameList is a list of names separated by "and" (see ParseNames).
+	DataNameList Datatype = "namelist"
+	// DataLiteralList is a list of plain text values separated by "and".
+	DataLiteralList Datatype = "list"
+	// DataDate is a date in YYYY[-MM[-DD]] format, or a range of dates
+	// separated by '/'.
+	DataDate Datatype = "date"
+	// DataRange is a list of ranges separated by commas, such as "1-10, 15".
+	DataRange Datatype = "range"
+	// DataURI is an absolute URI.
+	DataURI Datatype = "uri"
+	// DataVerbatim is a value that is never modified, such as a DOI.
+	DataVerbatim Datatype = "verbatim"
+	// DataInteger is an integer number.
+	DataInteger Datatype = "integer"
+	// DataKey is the key of another entry.
+	DataKey Datatype = "key"
+)
+
+var datatypes = map[Datatype]bool{
+	DataLiteral:     true,
+	DataNameList:    true,
+	DataLiteralList: true,
+	DataDate:        true,
+	DataRange:       true,
+	DataURI:         true,
+	DataVerbatim:    true,
+	DataInteger:     true,
+	DataKey:         true,
+}
+
+// defaultDatatypes holds the datatype of well-known BibLaTeX fields. It is
+// used when an entry type does not declare the datatype of a field.
+var defaultDatatypes = map[string]Datatype{
+	"author":       DataNameList,
+	"editor":       DataNameList,
+	"editora":      DataNameList,
+	"editorb":      DataNameList,
+	"editorc":      DataNameList,
+	"bookauthor":   DataNameList,
+	"translator":   DataNameList,
+	"annotator":    DataNameList,
+	"commentator":  DataNameList,
+	"introduction": DataNameList,
+	"foreword":     DataNameList,
+	"afterword":    DataNameList,
+	"holder":       DataNameList,
+	"publisher":    DataLiteralList,
+	"location":     DataLiteralList,
+	"institution":  DataLiteralList,
+	"organization": DataLiteralList,
+	"language":     DataLiteralList,
+	"date":         DataDate,
+	"urldate":      DataDate,
+	"origdate":     DataDate,
+	"eventdate":    DataDate,
+	"pages":        DataRange,
+	"url":          DataURI,
+	"doi":          DataVerbatim,
+	"eprint":       DataVerbatim,
+	"file":         DataVerbatim,
+	"volumes":      DataInteger,
+	"pagetotal":    DataInteger,
+	"crossref":     DataKey,
+	"xref":         DataKey,
+}
+
+// Field describes a field of an entry type. In the types file, a field can be
+// defined only by its description:
+//
+//	title: Title of the article.
+//
+// or with its metadata:
+//
+//	pages:
+//	  desc: Range of pages of the article.
+//	  type: range
+//	  default: ""
+//	  pattern: "^[0-9]+(--[0-9]+)?$"
+//
+// If the datatype is not defined, it is guessed from the name of the field,
+// and defaults to DataLiteral. Values that do not match the pattern are
+// reported by Validate.
+type Field struct {
+	Description string   `yaml:"desc"`
+	Datatype    Datatype `yaml:"type"`
+	Default     string   `yaml:"default"`
+	Pattern     string   `yaml:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (f *Field) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var desc string
+	if err := unmarshal(&desc); err == nil {
+		f.Description = desc
+		return nil
+	}
+
+	type field Field
+	return unmarshal((*field)(f))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface. Fields without
+// metadata are written only by their description.
+func (f *Field) MarshalYAML() (interface{}, error) {
+	if f.Datatype == "" && f.Default == "" && f.Pattern == "" {
+		return f.Description, nil
+	}
+
+	type field Field
+	return (*field)(f), nil
+}
+
+// String implements the Stringer interface.
+func (f *Field) String() string {
+	return f.Description
+}
+
+// compile checks the datatype and compiles the pattern of the field.
+func (f *Field) compile(name string) error {
+	if f.Datatype == "" {
+		f.Datatype = defaultDatatype(name)
+	}
+	if !datatypes[f.Datatype] {
+		return fmt.Errorf("field %q has an unknown type %q", name, f.Datatype)
+	}
+	if f.Pattern != "" {
+		rx, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return fmt.Errorf("field %q has an invalid pattern: %v", name, err)
+		}
+		f.pattern = rx
+	}
+	return nil
+}
+
+func defaultDatatype(field string) Datatype {
+	if t, ok := defaultDatatypes[field]; ok {
+		return t
+	}
+	return DataLiteral
+}
+
+// Field returns the definition of a required or optional field of the entry
+// type. It returns nil if the field is not defined.
+func (e *EntryType) Field(name string) *Field {
+	if f, ok := e.Required[name]; ok && f != nil {
+		return f
+	}
+	if f, ok := e.Optional[name]; ok && f != nil {
+		return f
+	}
+	return nil
+}
+
+// Datatype returns the datatype of a field of the entry. Fields not defined by
+// the entry type get the datatype of the well-known BibLaTeX field with the
+// same name, or DataLiteral.
+func (e *Entry) Datatype(field string) Datatype {
+	if et, ok := EntryTypes[e.Type]; ok {
+		if f := et.Field(field); f != nil && f.Datatype != "" {
+			return f.Datatype
+		}
+	}
+	return defaultDatatype(field)
+}
+
+// Literals returns the values of a literal list field of the entry, such as
+// publisher or location.
+func (e *Entry) Literals(field string) []string {
+	var list []string
+	var current []string
+
+	for _, word := range nameWords(e.field(field)) {
+		if strings.EqualFold(word, "and") {
+			list = append(list, strings.Join(current, " "))
+			current = nil
+			continue
+		}
+		current = append(current, word)
+	}
+	if len(current) > 0 {
+		list = append(list, strings.Join(current, " "))
+	}
+
+	return list
+}
+
+// Date represents a date with optional month and day. A zero Month or Day
+// means that part of the date is unknown.
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// ParseDate parses a date in YYYY[-MM[-DD]] format. If the value is a range
+// of dates, such as 1988/1992, the start of the range is returned.
+func ParseDate(s string) (Date, error) {
+	var d Date
+	s = strings.TrimSpace(s)
+	if err := checkDate(s); err != nil {
+		return d, getError("ParseDate", errNotDefined, err)
+	}
+	if i := strings.IndexRune(s, '/'); i != -1 {
+		if i == 0 {
+			s = s[1:]
+		} else {
+			s = s[:i]
+		}
+	}
+
+	parts := strings.Split(s, "-")
+	d.Year, _ = strconv.Atoi(parts[0])
+	if len(parts) > 1 {
+		d.Month, _ = strconv.Atoi(parts[1])
+	}
+	if len(parts) > 2 {
+		d.Day, _ = strconv.Atoi(parts[2])
+	}
+
+	return d, nil
+}
+
+// String implements the Stringer interface. It returns the date in
+// YYYY[-MM[-DD]] format.
+func (d Date) String() string {
+	switch {
+	case d.Month == 0:
+		return fmt.Sprintf("%04d", d.Year)
+	case d.Day == 0:
+		return fmt.Sprintf("%04d-%02d", d.Year, d.Month)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Date returns the value of a date field of the entry.
+func (e *Entry) Date(field string) (Date, error) {
+	return ParseDate(e.field(field))
+}
+
+// Range represents a range of pages. End is empty if the range is a single
+// page.
+type Range struct {
+	Start string
+	End   string
+}
+
+// String implements the Stringer interface. It returns the range using "--"
+// as separator.
+func (r Range) String() string {
+	if r.End == "" || r.End == r.Start {
+		return r.Start
+	}
+	return r.Start + "--" + r.End
+}
+
+// ParseRanges parses a list of ranges separated by commas, such as
+// "1-10, 15" or "1--10".
+func ParseRanges(s string) ([]Range, error) {
+	if err := checkRange(s); err != nil {
+		return nil, getError("ParseRanges", errNotDefined, err)
+	}
+
+	var ranges []Range
+	for _, part := range strings.Split(s, ",") {
+		m := rangeRx.FindStringSubmatch(strings.TrimSpace(part))
+		ranges = append(ranges, Range{Start: m[1], End: m[2]})
+	}
+
+	return ranges, nil
+}
+
+// Ranges returns the value of a range field of the entry, such as pages.
+func (e *Entry) Ranges(field string) ([]Range, error) {
+	return ParseRanges(e.field(field))
+}
+
+// Integer returns the value of an integer field of the entry.
+func (e *Entry) Integer(field string) (int, error) {
+	i, err := strconv.Atoi(strings.TrimSpace(e.field(field)))
+	if err != nil {
+		return 0, getError("Integer", errNotDefined, err)
+	}
+	return i, nil
+}
+
+// formatField formats the value of a field of the entry according to its
+// datatype for BibLaTeX output. Name lists are normalized and ranges use "--"
+// as separator. Values that cannot be parsed are returned as they are.
+func (e *Entry) formatField(field, value string) string {
+	switch e.Datatype(field) {
+	case DataNameList:
+		return FormatNames(ParseNames(value))
+	case DataRange:
+		ranges, err := ParseRanges(value)
+		if err != nil {
+			return value
+		}
+		s := make([]string, len(ranges))
+		for i, r := range ranges {
+			s[i] = r.String()
+		}
+		return strings.Join(s, ", ")
+	}
+	return value
+}
+
+// checkValue checks the value of a field of the entry against its datatype and
+// pattern.
+func (e *Entry) checkValue(field, value string) (ProblemKind, error) {
+	if et, ok := EntryTypes[e.Type]; ok {
+		if f := et.Field(field); f != nil && f.pattern != nil && !f.pattern.MatchString(value) {
+			return InvalidPattern, fmt.Errorf("%q does not match %q", value, f.Pattern)
+		}
+	}
+
+	switch field {
+	case "doi":
+		return InvalidDOI, checkDOI(value)
+	case "isbn":
+		return InvalidISBN, checkISBN(value)
+	case "issn":
+		return InvalidISSN, checkISSN(value)
+	}
+
+	switch e.Datatype(field) {
+	case DataDate:
+		return InvalidDate, checkDate(value)
+	case DataURI:
+		return InvalidURL, checkURL(value)
+	case DataRange:
+		return InvalidRange, checkRange(value)
+	case DataInteger:
+		if _, err := strconv.Atoi(value); err != nil {
+			return InvalidInteger, fmt.Errorf("%q is not an integer", value)
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,168 @@
+package scholar
+
+import (
+	"testing"
+)
+
+var mockTypedEntryTypes = []byte(`# Mock Entry Types with typed fields
+
+report:
+  desc: A technical report.
+  req:
+    author: Author(s) of the report.
+    title: Title of the report.
+    date:
+      desc: YYYY-MM-DD format.
+      type: date
+    number:
+      desc: Number of the report.
+      type: literal
+      pattern: "^[A-Z]+-[0-9]+$"
+  opt:
+    langid:
+      desc: Language of the report.
+      default: english
+    pagetotal: Total number of pages.
+    pages: Range of pages.
+    contact:
+      desc: Person in charge of the report.
+      type: namelist
+`)
+
+func TestLoadTypes_Fields(t *testing.T) {
+	err := loadTypes(mockTypedEntryTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	et := EntryTypes["report"]
+	tests := []struct {
+		field string
+		want  Datatype
+	}{
+		{"author", DataNameList},
+		{"title", DataLiteral},
+		{"date", DataDate},
+		{"number", DataLiteral},
+		{"pagetotal", DataInteger},
+		{"pages", DataRange},
+		{"contact", DataNameList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			f := et.Field(tt.field)
+			if f == nil {
+				t.Fatalf("field %q was not loaded", tt.field)
+			}
+			if f.Datatype != tt.want {
+				t.Errorf("Datatype = %q, want %q", f.Datatype, tt.want)
+			}
+		})
+	}
+	if got := et.Field("title").Description; got != "Title of the report." {
+		t.Errorf("Description = %q, want %q", got, "Title of the report.")
+	}
+
+	e, err := NewEntry("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Optional["langid"]; got != "english" {
+		t.Errorf("NewEntry() did not set the default value: got %q, want %q", got, "english")
+	}
+
+	invalid := map[string][]byte{
+		"unknown type":    []byte("misc:\n  req:\n    title:\n      type: unknown\n"),
+		"invalid pattern": []byte("misc:\n  req:\n    title:\n      pattern: \"[\"\n"),
+	}
+	for name, types := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if err := loadTypes(types); err == nil {
+				t.Fatal("loadTypes() did not return an error")
+			} else {
+				t.Log("Expected error:\n", err)
+			}
+		})
+	}
+}
+
+func TestEntry_TypedAccessors(t *testing.T) {
+	err := loadTypes(mockTypedEntryTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEntry("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Required["date"] = "2006-01-02"
+	e.Optional["pages"] = "1-10, 15"
+	e.Optional["pagetotal"] = "42"
+	e.Optional["publisher"] = "Springer and {Barnes and Noble}"
+
+	d, err := e.Date("date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != (Date{2006, 1, 2}) || d.String() != "2006-01-02" {
+		t.Errorf("Date() = %v, want 2006-01-02", d)
+	}
+
+	ranges, err := e.Ranges("pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 || ranges[0].String() != "1--10" || ranges[1].String() != "15" {
+		t.Errorf("Ranges() = %v, want [1--10 15]", ranges)
+	}
+
+	if n, err := e.Integer("pagetotal"); err != nil || n != 42 {
+		t.Errorf("Integer() = %d, %v, want 42", n, err)
+	}
+
+	list := e.Literals("publisher")
+	if len(list) != 2 || list[0] != "Springer" || list[1] != "{Barnes and Noble}" {
+		t.Errorf("Literals() = %q, want [Springer {Barnes and Noble}]", list)
+	}
+
+	if got := e.formatField("pages", "1-10, 15"); got != "1--10, 15" {
+		t.Errorf("formatField() = %q, want %q", got, "1--10, 15")
+	}
+}
+
+func TestEntry_ValidateTyped(t *testing.T) {
+	err := loadTypes(mockTypedEntryTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field string
+		value string
+		kind  ProblemKind
+	}{
+		{"number", "123", InvalidPattern},
+		{"pagetotal", "many", InvalidInteger},
+		{"pages", "10-1", InvalidRange},
+		{"date", "yesterday", InvalidDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			e, err := NewEntry("report")
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.Required["author"] = "Last, First"
+			e.Required["title"] = "The Title"
+			e.Required["date"] = "2006"
+			e.Required["number"] = "TR-1"
+			e.Set(tt.field, tt.value)
+
+			problems := e.Validate()
+			if len(problems) != 1 || problems[0].Kind != tt.kind {
+				t.Errorf("Validate() = %v, want %s", problems, tt.kind)
+			}
+		})
+	}
+}
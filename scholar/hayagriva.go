@@ -0,0 +1,277 @@
+package scholar
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// hayagrivaType is the Hayagriva type of an entry type. If Parent is set, the
+// entry is contained in a parent of that type, such as an article in a
+// periodical.
+type hayagrivaType struct {
+	Type   string
+	Parent string
+}
+
+type exHayagriva struct {
+	types map[string]hayagrivaType
+	dict  map[string]string
+	// parent lists the fields that describe the parent of the entry.
+	parent map[string]bool
+}
+
+// hayagrivaName returns the name as "von Last, First" or, if it has a suffix,
+// as a map of its parts. Names enclosed in braces are returned as they are.
+func hayagrivaName(n Name) interface{} {
+	if n.First == "" && n.Von == "" && n.Jr == "" {
+		return unbrace(n.Last)
+	}
+	if n.Jr == "" {
+		return unbrace(strings.TrimSpace(n.Von+" "+n.Last) + ", " + n.First)
+	}
+	name := yaml.MapSlice{{Key: "name", Value: unbrace(n.Last)}}
+	if n.First != "" {
+		name = append(name, yaml.MapItem{Key: "given-name", Value: unbrace(n.First)})
+	}
+	if n.Von != "" {
+		name = append(name, yaml.MapItem{Key: "prefix", Value: unbrace(n.Von)})
+	}
+	return append(name, yaml.MapItem{Key: "suffix", Value: unbrace(n.Jr)})
+}
+
+// hayagrivaValue returns value as an integer if possible.
+func hayagrivaValue(value string) interface{} {
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	return value
+}
+
+// hayagrivaSet sets the value of a key of m, adding it if necessary.
+func hayagrivaSet(m *yaml.MapSlice, key string, value interface{}) {
+	for i := range *m {
+		if (*m)[i].Key == key {
+			(*m)[i].Value = value
+			return
+		}
+	}
+	*m = append(*m, yaml.MapItem{Key: key, Value: value})
+}
+
+// hayagrivaGet returns the value of a key of m, or nil if it is not found.
+func hayagrivaGet(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// item returns the entry as a Hayagriva entry. The fields of the parent, such
+// as journaltitle or booktitle, are written in the parent entry.
+func (ex *exHayagriva) item(e *Entry) yaml.MapSlice {
+	t, ok := ex.types[e.Type]
+	if !ok {
+		t = ex.types["misc"]
+	}
+
+	item := yaml.MapSlice{{Key: "type", Value: t.Type}}
+	var parent yaml.MapSlice
+	if t.Parent != "" {
+		parent = yaml.MapSlice{{Key: "type", Value: t.Parent}}
+	}
+
+	// key: value
+	write := func(field, value string) {
+		key, ok := ex.dict[field]
+		if !ok {
+			return
+		}
+		m := &item
+		if ex.parent[field] && parent != nil {
+			m = &parent
+		} else if key == "title" && field != "title" {
+			return
+		}
+
+		switch e.Datatype(field) {
+		case DataNameList:
+			var names []interface{}
+			for _, n := range ParseNames(value) {
+				if !n.Others() {
+					names = append(names, hayagrivaName(n))
+				}
+			}
+			if key == "translator" {
+				hayagrivaSet(m, "affiliated", []yaml.MapSlice{{
+					{Key: "role", Value: key},
+					{Key: "names", Value: names},
+				}})
+				return
+			}
+			hayagrivaSet(m, key, names)
+		case DataDate:
+			// Hayagriva has no date ranges, the first known end is used
+			for _, date := range strings.Split(value, "/") {
+				if date != "" && date != ".." {
+					hayagrivaSet(m, key, date)
+					break
+				}
+			}
+		case DataRange:
+			ranges, err := ParseRanges(value)
+			if err != nil {
+				hayagrivaSet(m, key, value)
+				return
+			}
+			var rs []string
+			for _, r := range ranges {
+				rs = append(rs, strings.Replace(r.String(), "--", "-", 1))
+			}
+			hayagrivaSet(m, key, strings.Join(rs, ","))
+		default:
+			switch key {
+			case "title":
+				if sub := e.field("subtitle"); field == "title" && sub != "" {
+					value += ": " + sub
+				}
+			case "url":
+				if date := e.field("urldate"); date != "" {
+					hayagrivaSet(m, key, yaml.MapSlice{
+						{Key: "value", Value: value},
+						{Key: "date", Value: date},
+					})
+					return
+				}
+			case "doi", "isbn", "issn":
+				serial, _ := hayagrivaGet(*m, "serial-number").(yaml.MapSlice)
+				hayagrivaSet(&serial, key, value)
+				hayagrivaSet(m, "serial-number", serial)
+				return
+			}
+			hayagrivaSet(m, key, hayagrivaValue(value))
+		}
+	}
+
+	fields := make([]string, 0, len(e.Required))
+	for field := range e.Required {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := strings.TrimSpace(e.Required[field]); value != "" {
+			write(field, value)
+		}
+	}
+
+	fields = fields[:0]
+	for field := range e.Optional {
+		if _, ok := e.Required[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := strings.TrimSpace(e.Optional[field]); value != "" {
+			write(field, value)
+		}
+	}
+
+	if len(parent) > 1 {
+		item = append(item, yaml.MapItem{Key: "parent", Value: parent})
+	}
+	return item
+}
+
+func (ex *exHayagriva) marshal(entries []*Entry) string {
+	doc := yaml.MapSlice{}
+	for _, e := range entries {
+		doc = append(doc, yaml.MapItem{Key: e.GetKey(), Value: ex.item(e)})
+	}
+	d, err := yaml.Marshal(doc)
+	if err != nil {
+		return ""
+	}
+	return string(d)
+}
+
+// Begin implements the Exporter interface. The entries are written as
+// a single YAML document.
+func (ex *exHayagriva) Begin(w io.Writer) error {
+	return nil
+}
+
+// Entry implements the Exporter interface.
+func (ex *exHayagriva) Entry(w io.Writer, e *Entry, i int) error {
+	_, err := io.WriteString(w, ex.marshal([]*Entry{e}))
+	return err
+}
+
+// End implements the Exporter interface.
+func (ex *exHayagriva) End(w io.Writer) error {
+	return nil
+}
+
+var hayagriva = &exHayagriva{
+	types: map[string]hayagrivaType{
+		"article":       {"article", "periodical"},
+		"book":          {"book", ""},
+		"mvbook":        {"book", ""},
+		"inbook":        {"chapter", "book"},
+		"collection":    {"anthology", ""},
+		"incollection":  {"chapter", "anthology"},
+		"proceedings":   {"proceedings", ""},
+		"inproceedings": {"article", "proceedings"},
+		"report":        {"report", ""},
+		"manual":        {"reference", ""},
+		"thesis":        {"thesis", ""},
+		"online":        {"web", ""},
+		"patent":        {"patent", ""},
+		"software":      {"repository", ""},
+		"dataset":       {"repository", ""},
+		"unpublished":   {"manuscript", ""},
+		"misc":          {"misc", ""},
+	},
+	dict: map[string]string{
+		"author":       "author",
+		"editor":       "editor",
+		"translator":   "translator",
+		"title":        "title",
+		"journaltitle": "title",
+		"booktitle":    "title",
+		"date":         "date",
+		"publisher":    "publisher",
+		"location":     "location",
+		"institution":  "organization",
+		"organization": "organization",
+		"volume":       "volume",
+		"number":       "issue",
+		"edition":      "edition",
+		"pages":        "page-range",
+		"pagetotal":    "page-total",
+		"doi":          "doi",
+		"isbn":         "isbn",
+		"issn":         "issn",
+		"url":          "url",
+		"language":     "language",
+		"note":         "note",
+		"abstract":     "abstract",
+	},
+	parent: map[string]bool{
+		"journaltitle": true,
+		"booktitle":    true,
+		"editor":       true,
+		"publisher":    true,
+		"location":     true,
+		"volume":       true,
+		"number":       true,
+		"edition":      true,
+		"isbn":         true,
+		"issn":         true,
+	},
+}
@@ -0,0 +1,123 @@
+package scholar
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestExHayagriva_ExportList(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["author"] = "von Last, First and {World Health Organization} and Neumann, Jr., John"
+	entry.Optional["volume"] = "3"
+	entry.Optional["number"] = "2"
+	entry.Optional["pages"] = "10--20, 25"
+	entry.Optional["issn"] = "1234-5678"
+
+	out, err := ExportList([]*Entry{entry}, "hayagriva")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]struct {
+		Type   string            `yaml:"type"`
+		Title  string            `yaml:"title"`
+		Author []interface{}     `yaml:"author"`
+		Date   string            `yaml:"date"`
+		Pages  string            `yaml:"page-range"`
+		Serial map[string]string `yaml:"serial-number"`
+		Parent struct {
+			Type   string            `yaml:"type"`
+			Title  string            `yaml:"title"`
+			Volume int               `yaml:"volume"`
+			Issue  int               `yaml:"issue"`
+			Serial map[string]string `yaml:"serial-number"`
+		} `yaml:"parent"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := doc["last2006"]
+	if !ok {
+		t.Fatalf("ExportList() has no entry last2006:\n%s", out)
+	}
+
+	if got.Type != "article" || got.Parent.Type != "periodical" {
+		t.Errorf("type = %q, parent type = %q, want article, periodical", got.Type, got.Parent.Type)
+	}
+	if got.Title != "The Title" || got.Parent.Title != "The Journal" {
+		t.Errorf("title = %q, parent title = %q", got.Title, got.Parent.Title)
+	}
+	if len(got.Author) != 3 || got.Author[0] != "von Last, First" || got.Author[1] != "World Health Organization" {
+		t.Errorf("author = %v", got.Author)
+	}
+	if jr, ok := got.Author[2].(map[interface{}]interface{}); !ok || jr["suffix"] != "Jr." || jr["name"] != "Neumann" {
+		t.Errorf("author[2] = %v, want name with suffix", got.Author[2])
+	}
+	if got.Date != "2006-01-02" {
+		t.Errorf("date = %q, want 2006-01-02", got.Date)
+	}
+	if got.Pages != "10-20,25" {
+		t.Errorf("page-range = %q, want 10-20,25", got.Pages)
+	}
+	if got.Parent.Volume != 3 || got.Parent.Issue != 2 {
+		t.Errorf("volume = %d, issue = %d, want 3, 2", got.Parent.Volume, got.Parent.Issue)
+	}
+	if got.Serial["doi"] != "123/456789" || got.Parent.Serial["issn"] != "1234-5678" {
+		t.Errorf("serial-number = %v, parent serial-number = %v", got.Serial, got.Parent.Serial)
+	}
+}
+
+func TestExHayagriva_Export(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := NewEntry("book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Key = "knuth1984"
+	entry.Required["title"] = "The Book"
+	entry.Required["author"] = "Knuth, Donald E."
+	entry.Required["date"] = "1984"
+	entry.Optional["publisher"] = "Addison-Wesley"
+
+	out, err := entry.Export("hayagriva")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `knuth1984:
+  type: book
+  author:
+  - Knuth, Donald E.
+  date: "1984"
+  title: The Book
+  publisher: Addison-Wesley
+`
+	if out != want {
+		t.Errorf("Export() =\n%s\nwant\n%s", out, want)
+	}
+
+	t.Run("date range", func(t *testing.T) {
+		for date, want := range map[string]string{
+			"1984/1986":  `date: "1984"`,
+			"1984/":      `date: "1984"`,
+			"/1986":      `date: "1986"`,
+			"../1986":    `date: "1986"`,
+			"1984-03/..": `date: 1984-03`,
+		} {
+			entry.Required["date"] = date
+			out, err := entry.Export("hayagriva")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, want) {
+				t.Errorf("Export() with date %q =\n%s\nwant %s", date, out, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package scholar
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// HistoryDir is the name of the directory, inside the directory of an entry,
+// that holds the previous versions of the entry file:
+//
+//	<path>/<key>/.history/<timestamp>.yaml
+const HistoryDir = ".history"
+
+// revisionLayout is the time layout of the file names of the revisions.
+const revisionLayout = "20060102T150405.000000000Z"
+
+// Revision is a previous version of an entry. Revisions are numbered from 1,
+// the oldest version.
+type Revision struct {
+	N    int
+	Time time.Time
+	Path string
+}
+
+// String implements the Stringer interface.
+func (r Revision) String() string {
+	return fmt.Sprintf("%d %s", r.N, r.Time.Local().Format("2006-01-02 15:04:05"))
+}
+
+// saveRevision saves data as the newest revision of the entry with the given
+// key, unless it is the same as the newest revision.
+func (l *Library) saveRevision(key string, data []byte) error {
+	dir := filepath.Join(l.Dir(key), HistoryDir)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	revs, err := l.History(key)
+	if err != nil {
+		return err
+	}
+	if len(revs) > 0 {
+		last, err := ioutil.ReadFile(revs[len(revs)-1].Path)
+		if err == nil && bytes.Equal(last, data) {
+			return nil
+		}
+	}
+
+	name := time.Now().UTC().Format(revisionLayout)
+	file := filepath.Join(dir, name+".yaml")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			break
+		}
+		file = filepath.Join(dir, fmt.Sprintf("%s-%d.yaml", name, i))
+	}
+
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// History returns the previous versions of the entry with the given key,
+// sorted from the oldest. A new version is saved every time the entry is
+// changed by Put or Edit.
+func (l *Library) History(key string) ([]Revision, error) {
+	if err := checkKey("History", key); err != nil {
+		return nil, err
+	}
+	if !l.Exists(key) {
+		return nil, getError("History", ErrEntryNotFound, nil).
+			info(fmt.Sprintf("no entry with key %q", key))
+	}
+
+	dir := filepath.Join(l.Dir(key), HistoryDir)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, getError("History", ErrIO, err)
+	}
+
+	var revs []Revision
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".yaml" {
+			continue
+		}
+		r := Revision{
+			Time: f.ModTime(),
+			Path: filepath.Join(dir, f.Name()),
+		}
+		name := strings.TrimSuffix(f.Name(), ".yaml")
+		if i := strings.IndexRune(name, '-'); i > 0 {
+			name = name[:i]
+		}
+		if t, err := time.Parse(revisionLayout, name); err == nil {
+			r.Time = t
+		}
+		revs = append(revs, r)
+	}
+
+	// Revisions saved at the same time are sorted by their numbered
+	// suffix: name.yaml, name-2.yaml, ..., name-10.yaml
+	sort.SliceStable(revs, func(i, j int) bool {
+		if !revs[i].Time.Equal(revs[j].Time) {
+			return revs[i].Time.Before(revs[j].Time)
+		}
+		return len(revs[i].Path) < len(revs[j].Path) ||
+			len(revs[i].Path) == len(revs[j].Path) && revs[i].Path < revs[j].Path
+	})
+	for i := range revs {
+		revs[i].N = i + 1
+	}
+
+	return revs, nil
+}
+
+// Revision returns the version n of the entry with the given key (see
+// History).
+func (l *Library) Revision(key string, n int) (*Entry, error) {
+	revs, err := l.History(key)
+	if err != nil {
+		return nil, getError("Revision", errNotDefined, err)
+	}
+	if n < 1 || n > len(revs) {
+		return nil, getError("Revision", ErrEntryNotFound, nil).
+			info(fmt.Sprintf("entry %q has no version %d", key, n))
+	}
+
+	d, err := ioutil.ReadFile(revs[n-1].Path)
+	if err != nil {
+		return nil, getError("Revision", ErrIO, err)
+	}
+
+	var e Entry
+	if err := yaml.Unmarshal(d, &e); err != nil {
+		return nil, getError("Revision", ErrParse, err).
+			info(fmt.Sprintf("could not read %s", revs[n-1].Path))
+	}
+
+	return &e, nil
+}
+
+// Revert restores the version n of the entry with the given key, and returns
+// the restored entry. The current version is saved in the history first, as
+// the newest version, so a revert can be undone by reverting to it. The
+// restored entry keeps the current key.
+func (l *Library) Revert(key string, n int) (*Entry, error) {
+	e, err := l.Revision(key, n)
+	if err != nil {
+		return nil, getError("Revert", errNotDefined, err)
+	}
+
+	e.Key = key
+	if err := l.Put(e); err != nil {
+		return nil, getError("Revert", errNotDefined, err)
+	}
+
+	return e, nil
+}
+
+// SaveRevision saves the entry file of the entry with key from as the newest
+// revision of the entry with key to, so it can be restored with Revert after
+// from is deleted, for example, when from is merged into to.
+func (l *Library) SaveRevision(to, from string) error {
+	for _, key := range []string{to, from} {
+		if err := checkKey("SaveRevision", key); err != nil {
+			return err
+		}
+	}
+	if !l.Exists(to) {
+		return getError("SaveRevision", ErrEntryNotFound, nil).
+			info(fmt.Sprintf("no entry with key %q", to))
+	}
+	d, err := ioutil.ReadFile(filepath.Join(l.Dir(from), EntryFile))
+	if err != nil {
+		return getError("SaveRevision", ErrEntryNotFound, err).
+			info(fmt.Sprintf("no entry with key %q", from))
+	}
+
+	if err := l.saveRevision(to, d); err != nil {
+		return getError("SaveRevision", ErrIO, err)
+	}
+	return nil
+}
+
+// Edit calls edit with the path of the entry file of the entry with the given
+// key, so it can be modified in place, for example, with a text editor. If
+// the file is changed, its previous version is saved in the history. It
+// returns the edited entry.
+func (l *Library) Edit(key string, edit func(file string) error) (*Entry, error) {
+	if err := checkKey("Edit", key); err != nil {
+		return nil, err
+	}
+	file := filepath.Join(l.Dir(key), EntryFile)
+	old, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, getError("Edit", ErrEntryNotFound, err).
+			info(fmt.Sprintf("no entry with key %q", key))
+	}
+
+	if err := edit(file); err != nil {
+		return nil, getError("Edit", errNotDefined, err)
+	}
+
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, getError("Edit", ErrIO, err)
+	}
+	if !bytes.Equal(old, d) {
+		if err := l.saveRevision(key, old); err != nil {
+			return nil, getError("Edit", ErrIO, err)
+		}
+	}
+
+	return l.Get(key)
+}
@@ -0,0 +1,219 @@
+package scholar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLibrary_History(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+	revs, err := l.History("last2006")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 0 {
+		t.Fatalf("new entry has %d versions, want 0", len(revs))
+	}
+
+	for _, title := range []string{"The Title", "Second Title", "Third Title", "Third Title"} {
+		entry.Required["title"] = title
+		if err := l.Put(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revs, err = l.History("last2006")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("got %d versions, want 2: %v", len(revs), revs)
+	}
+	for i, want := range []string{"The Title", "Second Title"} {
+		if revs[i].N != i+1 {
+			t.Errorf("version %d is numbered %d", i+1, revs[i].N)
+		}
+		e, err := l.Revision("last2006", i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e.Required["title"]; got != want {
+			t.Errorf("version %d: got %q, want %q", i+1, got, want)
+		}
+	}
+
+	t.Run("missing version", func(t *testing.T) {
+		_, err := l.Revision("last2006", 3)
+		if !IsError(ErrEntryNotFound, err) {
+			t.Fatal("error other than ErrEntryNotFound:", err)
+		}
+		t.Log("Expected error:\n", err)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := l.History("../last2006"); !IsError(ErrInvalidEntry, err) {
+			t.Error("History: error other than ErrInvalidEntry:", err)
+		}
+		if _, err := l.Revision("../last2006", 1); !IsError(ErrInvalidEntry, err) {
+			t.Error("Revision: error other than ErrInvalidEntry:", err)
+		}
+		if err := l.SaveRevision("last2006", "../last2006"); !IsError(ErrInvalidEntry, err) {
+			t.Error("SaveRevision: error other than ErrInvalidEntry:", err)
+		}
+		if _, err := l.Edit("../last2006", func(string) error { return nil }); !IsError(ErrInvalidEntry, err) {
+			t.Error("Edit: error other than ErrInvalidEntry:", err)
+		}
+	})
+
+	t.Run("revert", func(t *testing.T) {
+		e, err := l.Revert("last2006", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e.Required["title"]; got != "The Title" {
+			t.Errorf("got %q, want %q", got, "The Title")
+		}
+
+		got, err := l.Get("last2006")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Bib() != e.Bib() {
+			t.Errorf("reverted entry was not saved:\ngot:\n%v\nwant:\n%v", got.Bib(), e.Bib())
+		}
+
+		revs, err := l.History("last2006")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revs) != 3 {
+			t.Fatalf("got %d versions, want 3", len(revs))
+		}
+		last, err := l.Revision("last2006", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := last.Required["title"]; got != "Third Title" {
+			t.Errorf("version before revert: got %q, want %q", got, "Third Title")
+		}
+	})
+}
+
+func TestLibrary_HistoryGeneratedKey(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Key = ""
+	key := entry.GetKey()
+
+	for _, title := range []string{"The Title", "Second Title"} {
+		entry.Required["title"] = title
+		if err := l.Put(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revs, err := l.History(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 1 {
+		t.Errorf("got %d versions of %s, want 1", len(revs), key)
+	}
+	if _, err := os.Stat(filepath.Join(l.Path, HistoryDir)); !os.IsNotExist(err) {
+		t.Errorf("history saved in the library directory: %v", err)
+	}
+}
+
+func TestLibrary_Edit(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening the file without changes does not save a version
+	if _, err := l.Edit("last2006", func(string) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if revs, _ := l.History("last2006"); len(revs) != 0 {
+		t.Fatalf("got %d versions, want 0", len(revs))
+	}
+
+	e, err := l.Edit("last2006", func(file string) error {
+		d, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(file, []byte(strings.Replace(string(d), "The Title", "Edited", 1)), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Required["title"]; got != "Edited" {
+		t.Errorf("got %q, want %q", got, "Edited")
+	}
+
+	old, err := l.Revision("last2006", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := old.Required["title"]; got != "The Title" {
+		t.Errorf("previous version: got %q, want %q", got, "The Title")
+	}
+}
+
+func TestLibrary_SaveRevision(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+	other, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Key = "other2006"
+	other.Required["title"] = "Other Title"
+	if err := l.Put(other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.SaveRevision("last2006", "other2006"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete("other2006"); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := l.Revert("last2006", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Key != "last2006" || e.Required["title"] != "Other Title" {
+		t.Errorf("got %s with title %q, want last2006 with title %q", e.Key, e.Required["title"], "Other Title")
+	}
+
+	if err := l.SaveRevision("last2006", "missing"); !IsError(ErrEntryNotFound, err) {
+		t.Errorf("error = %v, want ErrEntryNotFound", err)
+	}
+}
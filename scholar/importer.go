@@ -0,0 +1,49 @@
+package scholar
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+type importer interface {
+	read(io.Reader) ([]*Entry, error)
+}
+
+var importers = map[string]importer{
+	"bibtex":   bibtexParser,
+	"biblatex": biblatexParser,
+	"csljson":  csljsonParser,
+	"ris":      risParser,
+	"endnote":  endnoteParser,
+}
+
+// ImportFormats returns the sorted names of the supported import formats.
+func ImportFormats() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func getImporter(format string) (importer, error) {
+	if im, ok := importers[format]; ok {
+		return im, nil
+	}
+	return nil, getError("Import", ErrFormatNotFound, nil).
+		info(fmt.Sprintf("%q is not a supported format", format))
+}
+
+// Import reads all the entries from r in the given format. If the format is
+// not supported, it returns an ErrFormatNotFound error.
+func Import(r io.Reader, format string) ([]*Entry, error) {
+	im, err := getImporter(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return im.read(r)
+}
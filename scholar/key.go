@@ -0,0 +1,251 @@
+package scholar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// KeyFormat is the template used by GetKey to generate the key of entries
+// without a key.
+var KeyFormat = MustParseKeyTemplate(DefaultKeyTemplate)
+
+// DefaultKeyTemplate generates keys with lastnameYEAR format.
+// For example: einstein1922
+const DefaultKeyTemplate = "{auth.lower}{year}"
+
+// KeyTemplate generates citation keys from the fields of an entry.
+//
+// A template is a text where each {placeholder} is replaced by a value of the
+// entry. The rest of the text is copied as it is. A placeholder has the form
+// {name[:N][.modifier...]}, where name can be:
+//
+//	auth          last name of the first author (or editor)
+//	authors       last names of the first N authors (all by default)
+//	year          year of the entry
+//	title         significant words of the title
+//	shorttitle    first N significant words of the title (3 by default)
+//	<field>       value of any other field of the entry
+//
+// Significant words are lower case words of the title that are not
+// stopwords, such as "the" or "of". The modifiers lower, upper, and
+// capitalize (upper case the first letter of each word) change the case of
+// the value.
+//
+// The generated key is transliterated to ASCII (Müller -> Muller) and any
+// character that is not a letter, a digit, '-', '_', or '.' is removed,
+// including spaces.
+//
+// For example, "{auth.lower}{year}{shorttitle:1}" generates
+// einstein1905electrodynamics for "On the Electrodynamics of Moving Bodies"
+// by Albert Einstein.
+type KeyTemplate struct {
+	src   string
+	parts []keyPart
+}
+
+type keyPart struct {
+	literal   string
+	name      string
+	n         int
+	modifiers []string
+}
+
+var keyModifiers = map[string]func(string) string{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"capitalize": capitalize,
+}
+
+// ParseKeyTemplate parses a key template. See KeyTemplate for the syntax.
+func ParseKeyTemplate(s string) (*KeyTemplate, error) {
+	t := &KeyTemplate{src: s}
+
+	rest := s
+	for rest != "" {
+		start := strings.IndexRune(rest, '{')
+		if start == -1 {
+			t.parts = append(t.parts, keyPart{literal: rest})
+			break
+		}
+		if start > 0 {
+			t.parts = append(t.parts, keyPart{literal: rest[:start]})
+		}
+		end := strings.IndexRune(rest[start:], '}')
+		if end == -1 {
+			return nil, getError("ParseKeyTemplate", ErrInvalidTemplate, nil).
+				info(fmt.Sprintf("missing '}' in %q", s))
+		}
+		end += start
+		p, err := parseKeyPart(rest[start+1 : end])
+		if err != nil {
+			return nil, getError("ParseKeyTemplate", ErrInvalidTemplate, nil).
+				info(fmt.Sprintf("%s in %q", err, s))
+		}
+		t.parts = append(t.parts, p)
+		rest = rest[end+1:]
+	}
+
+	return t, nil
+}
+
+// MustParseKeyTemplate is like ParseKeyTemplate but panics if the template
+// cannot be parsed.
+func MustParseKeyTemplate(s string) *KeyTemplate {
+	t, err := ParseKeyTemplate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseKeyPart(s string) (keyPart, error) {
+	var p keyPart
+
+	mods := strings.Split(s, ".")
+	p.name = strings.TrimSpace(mods[0])
+	if i := strings.IndexRune(p.name, ':'); i != -1 {
+		n, err := strconv.Atoi(p.name[i+1:])
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("invalid number %q in {%s}", p.name[i+1:], s)
+		}
+		p.n = n
+		p.name = p.name[:i]
+	}
+	if p.name == "" {
+		return p, fmt.Errorf("empty placeholder {%s}", s)
+	}
+
+	for _, m := range mods[1:] {
+		m = strings.TrimSpace(m)
+		if _, ok := keyModifiers[m]; !ok {
+			return p, fmt.Errorf("unknown modifier %q in {%s}", m, s)
+		}
+		p.modifiers = append(p.modifiers, m)
+	}
+
+	return p, nil
+}
+
+// String implements the Stringer interface.
+func (t *KeyTemplate) String() string {
+	return t.src
+}
+
+// Key returns the key of the entry generated with the template. If the
+// template produces an empty key, the type of the entry is used instead.
+func (t *KeyTemplate) Key(e *Entry) string {
+	key := new(strings.Builder)
+
+	for _, p := range t.parts {
+		if p.name == "" {
+			key.WriteString(p.literal)
+			continue
+		}
+		value := transliterate(p.value(e))
+		for _, m := range p.modifiers {
+			value = keyModifiers[m](value)
+		}
+		key.WriteString(value)
+	}
+
+	if k := cleanKey(key.String()); k != "" {
+		return k
+	}
+	return cleanKey(e.Type)
+}
+
+func (p keyPart) value(e *Entry) string {
+	switch p.name {
+	case "auth", "authors":
+		names := e.authorsOrEditors()
+		n := p.n
+		if p.name == "auth" {
+			n = 1
+		}
+		if n > 0 && len(names) > n {
+			names = names[:n]
+		}
+		var last []string
+		for _, n := range names {
+			if !n.Others() {
+				last = append(last, n.Last)
+			}
+		}
+		return strings.Join(last, " ")
+	case "year":
+		return e.Year()
+	case "title", "shorttitle":
+		words := titleWords(e.field("title"))
+		n := p.n
+		if p.name == "shorttitle" && n == 0 {
+			n = 3
+		}
+		if n > 0 && len(words) > n {
+			words = words[:n]
+		}
+		return strings.Join(words, " ")
+	}
+
+	return e.field(p.name)
+}
+
+// field returns the value of a required or optional field of the entry.
+func (e *Entry) field(name string) string {
+	if value := e.Required[name]; value != "" {
+		return value
+	}
+	return e.Optional[name]
+}
+
+// titleWords returns the lower case words of a title without stopwords.
+func titleWords(title string) []string {
+	title = strings.ToLower(transliterate(title))
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var significant []string
+	for _, w := range words {
+		if !stopwords[w] {
+			significant = append(significant, w)
+		}
+	}
+
+	return significant
+}
+
+// cleanKey transliterates s to ASCII and removes any character that should
+// not be part of a key.
+func cleanKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-', r == '_', r == '.':
+			return r
+		}
+		return -1
+	}, transliterate(s))
+}
+
+func capitalize(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.Title(strings.ToLower(w))
+	}
+	return strings.Join(words, " ")
+}
+
+var stopwords = map[string]bool{
+	"a": true, "about": true, "above": true, "after": true, "against": true,
+	"an": true, "and": true, "are": true, "as": true, "at": true,
+	"before": true, "between": true, "but": true, "by": true, "during": true,
+	"for": true, "from": true, "how": true, "in": true, "into": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true,
+	"or": true, "over": true, "the": true, "their": true, "through": true,
+	"to": true, "toward": true, "towards": true, "under": true, "upon": true,
+	"via": true, "what": true, "when": true, "where": true, "which": true,
+	"why": true, "with": true, "within": true, "without": true,
+}
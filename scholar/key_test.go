@@ -0,0 +1,94 @@
+package scholar
+
+import (
+	"testing"
+)
+
+func TestKeyTemplate_Key(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["author"] = "Einstein, Albert and Podolsky, Boris and Rosen, Nathan"
+	entry.Required["title"] = "On the Electrodynamics of Moving Bodies"
+	entry.Required["date"] = "1905-06-30"
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{DefaultKeyTemplate, "einstein1905"},
+		{"{auth.lower}{year}{shorttitle:1}", "einstein1905electrodynamics"},
+		{"{auth}_{year}", "Einstein_1905"},
+		{"{authors}{year}", "EinsteinPodolskyRosen1905"},
+		{"{authors:2.lower}", "einsteinpodolsky"},
+		{"{shorttitle.capitalize}", "ElectrodynamicsMovingBodies"},
+		{"{title.upper}", "ELECTRODYNAMICSMOVINGBODIES"},
+		{"{journaltitle.lower}-{year}", "thejournal-1905"},
+		{"{missing}", "article"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			kt, err := ParseKeyTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := kt.Key(entry); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyTemplate_Transliterate(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kt := MustParseKeyTemplate(DefaultKeyTemplate)
+
+	tests := []struct {
+		author string
+		want   string
+	}{
+		{"Müller, Hans", "muller2006"},
+		{"M{\\\"u}ller, Hans", "muller2006"},
+		{"Gauß, Carl Friedrich", "gauss2006"},
+		{"Łukasiewicz, Jan", "lukasiewicz2006"},
+		{"Erd\\H{o}s, Paul", "erdos2006"},
+		{"{World Health Organization}", "worldhealthorganization2006"},
+		{"Þórðarson, Jón", "thordarson2006"},
+		{"{\\O}rsted, Hans", "orsted2006"},
+		{"\\textsc{Smith}, John", "smith2006"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.author, func(t *testing.T) {
+			entry.Required["author"] = tt.author
+			if got := kt.Key(entry); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyTemplate(t *testing.T) {
+	invalid := []string{
+		"{auth",
+		"{auth.unknown}",
+		"{shorttitle:x}",
+		"{shorttitle:0}",
+		"{}",
+	}
+
+	for _, template := range invalid {
+		t.Run(template, func(t *testing.T) {
+			_, err := ParseKeyTemplate(template)
+			if !IsError(ErrInvalidTemplate, err) {
+				t.Fatal("error other than ErrInvalidTemplate:", err)
+			}
+			t.Log("Expected error:\n", err)
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package scholar
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// texData checks if the values of a datatype are written as LaTeX text in
+// BibTeX files. Other values, such as URLs, DOIs, dates, and ranges, are
+// written as they are.
+func texData(d Datatype) bool {
+	switch d {
+	case DataLiteral, DataNameList, DataLiteralList:
+		return true
+	}
+	return false
+}
+
+// texField returns the value of a field as it is written in a BibTeX file.
+// The LaTeX special characters &, %, _, and # are escaped, and, if ascii is
+// set, Unicode letters are written as LaTeX commands. Math segments, such as
+// $x^2$, are not modified.
+func (e *Entry) texField(field, value string, ascii bool) string {
+	if !texData(e.Datatype(field)) {
+		return value
+	}
+	value = latexText(value, escapeBib)
+	if ascii {
+		value = latexText(value, encodeLaTeX)
+	}
+	return value
+}
+
+// latexText applies f to the text of s outside math segments, which are
+// enclosed in $...$, $$...$$, \(...\), or \[...\].
+func latexText(s string, f func(string) string) string {
+	b := new(strings.Builder)
+	text := 0
+	for i := 0; i < len(s); i++ {
+		var closing string
+		switch {
+		case strings.HasPrefix(s[i:], `\(`):
+			closing = `\)`
+		case strings.HasPrefix(s[i:], `\[`):
+			closing = `\]`
+		case s[i] == '\\':
+			// escaped character, such as \$
+			i++
+			continue
+		case strings.HasPrefix(s[i:], "$$"):
+			closing = "$$"
+		case s[i] == '$':
+			closing = "$"
+		default:
+			continue
+		}
+
+		start := i + len(closing)
+		end := latexClosing(s[start:], closing)
+		if end < 0 {
+			continue
+		}
+		end += start + len(closing)
+		b.WriteString(f(s[text:i]))
+		b.WriteString(s[i:end])
+		text = end
+		i = end - 1
+	}
+	b.WriteString(f(s[text:]))
+	return b.String()
+}
+
+// latexClosing returns the index of the closing delimiter of a math segment,
+// or -1 if it is not closed.
+func latexClosing(s, closing string) int {
+	for i := 0; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], closing) {
+			return i
+		}
+		if s[i] == '\\' {
+			i++
+		}
+	}
+	return -1
+}
+
+// escapeBib escapes the characters &, %, _, and # that are not already
+// escaped.
+func escapeBib(s string) string {
+	b := new(strings.Builder)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			b.WriteByte(c)
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '&', '%', '_', '#':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// encodeLaTeX writes the accented letters, special letters, and typographic
+// punctuation of s as LaTeX commands, such as {\'e} for é. Other non-ASCII
+// characters are kept.
+func encodeLaTeX(s string) string {
+	b := new(strings.Builder)
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case latexAccents[r] != "":
+			b.WriteString("{" + latexAccents[r] + "}")
+		case latexLetters[r].cmd != "":
+			b.WriteString(`{\` + latexLetters[r].cmd + "}")
+		case latexSymbols[r] != "":
+			b.WriteString(latexSymbols[r])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// latexReplacer escapes all the characters with a special meaning in LaTeX.
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// escapeLaTeX escapes the characters with a special meaning in LaTeX, so s is
+// written as plain text. Unlike escapeBib, it also escapes backslashes, math,
+// and braces.
+func escapeLaTeX(s string) string {
+	return latexReplacer.Replace(s)
+}
+
+// decodeLaTeX replaces the LaTeX accents and special letters of s by their
+// Unicode letters, and unescapes the characters &, %, _, and #. Braces that
+// only enclose an accent, as in {\'e}, are removed. Other commands, braces,
+// and math segments are kept.
+func decodeLaTeX(s string) string {
+	return latexText(s, func(s string) string {
+		b := new(strings.Builder)
+		for i := 0; i < len(s); i++ {
+			switch s[i] {
+			case '{':
+				if r, n := latexCommand(s[i+1:]); n > 0 && strings.HasPrefix(s[i+1+n:], "}") {
+					b.WriteString(r)
+					i += n + 1
+					continue
+				}
+			case '\\':
+				if r, n := latexCommand(s[i:]); n > 0 {
+					b.WriteString(r)
+					i += n - 1
+					continue
+				}
+				// keep other escaped characters, such as \$ or \{
+				if i+1 < len(s) {
+					b.WriteByte(s[i])
+					i++
+				}
+			}
+			b.WriteByte(s[i])
+		}
+		return b.String()
+	})
+}
+
+// latexCommand decodes the command at the start of s. It returns the decoded
+// text and the length of the command, or 0 if s does not start with a known
+// command.
+func latexCommand(s string) (string, int) {
+	if len(s) < 2 || s[0] != '\\' {
+		return "", 0
+	}
+	if strings.IndexByte("&%_#", s[1]) >= 0 {
+		return s[1:2], 2
+	}
+
+	cmd, n := s[1:2], 2
+	if !strings.ContainsAny(cmd, "`'^~=.\"") {
+		for n < len(s) && isLetter(s[n]) {
+			n++
+		}
+		cmd = s[1:n]
+		if r, ok := latexCommands[cmd]; ok {
+			// the space or empty group that ends the command
+			switch {
+			case strings.HasPrefix(s[n:], "{}"):
+				n += 2
+			case strings.HasPrefix(s[n:], " "):
+				n++
+			}
+			return r, n
+		}
+		for n < len(s) && s[n] == ' ' {
+			n++
+		}
+	}
+
+	// accent argument: a letter, \i, or either in braces
+	arg, m := latexArg(s[n:])
+	if m == 0 {
+		if !strings.HasPrefix(s[n:], "{") {
+			return "", 0
+		}
+		arg, m = latexArg(s[n+1:])
+		if m == 0 || !strings.HasPrefix(s[n+1+m:], "}") {
+			return "", 0
+		}
+		m += 2
+	}
+	r, ok := latexDecoded[cmd+arg]
+	if !ok {
+		return "", 0
+	}
+	return string(r), n + m
+}
+
+func latexArg(s string) (string, int) {
+	switch {
+	case strings.HasPrefix(s, `\i`) && (len(s) == 2 || !isLetter(s[2])):
+		return "i", 2
+	case strings.HasPrefix(s, `\j`) && (len(s) == 2 || !isLetter(s[2])):
+		return "j", 2
+	case len(s) > 0 && isLetter(s[0]):
+		return s[:1], 1
+	}
+	return "", 0
+}
+
+// transliterate replaces the accented and special Latin letters of s, written
+// in Unicode or as LaTeX commands such as {\"u} or \ss, by their closest ASCII
+// letters (see latexASCII). Other LaTeX commands and braces are removed.
+func transliterate(s string) string {
+	s = decodeLaTeX(s)
+	b := new(strings.Builder)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '{' || c == '}':
+			continue
+		case c == '\\' && i+1 < len(s):
+			j := i + 1
+			for j < len(s) && isLetter(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				// Escaped character or accent of an unknown letter
+				i++
+				continue
+			}
+			for j < len(s) && s[j] == ' ' {
+				j++
+			}
+			i = j - 1
+			continue
+		}
+
+		r, size := rune(c), 1
+		if c >= utf8.RuneSelf {
+			r, size = utf8.DecodeRuneInString(s[i:])
+		}
+		if t, ok := latexASCII[r]; ok {
+			b.WriteString(t)
+		} else if !unicode.Is(unicode.Mn, r) {
+			b.WriteString(s[i : i+size])
+		}
+		i += size - 1
+	}
+
+	return b.String()
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// latexLetters are the special letters written as LaTeX commands, such as
+// {\ss} for ß, and their closest ASCII letters.
+var latexLetters = map[rune]struct{ cmd, ascii string }{
+	'ß': {"ss", "ss"}, 'æ': {"ae", "ae"}, 'Æ': {"AE", "AE"}, 'œ': {"oe", "oe"},
+	'Œ': {"OE", "OE"}, 'ø': {"o", "o"}, 'Ø': {"O", "O"}, 'å': {"aa", "a"},
+	'Å': {"AA", "A"}, 'ł': {"l", "l"}, 'Ł': {"L", "L"}, 'ı': {"i", "i"},
+}
+
+// latexCommands maps the command of a special letter to the letter.
+var latexCommands = func() map[string]string {
+	m := make(map[string]string, len(latexLetters))
+	for r, l := range latexLetters {
+		m[l.cmd] = string(r)
+	}
+	return m
+}()
+
+// latexSymbols are the punctuation marks written with ASCII characters.
+var latexSymbols = map[rune]string{
+	'–': "--", '—': "---", '‘': "`", '’': "'", '“': "``", '”': "''",
+	'…': `\ldots{}`, '\u00a0': "~",
+}
+
+// latexAccents are the accented letters written with LaTeX accent commands.
+var latexAccents = map[rune]string{
+	'À': "\\`A", 'Á': "\\'A", 'Â': "\\^A", 'Ã': "\\~A", 'Ä': "\\\"A",
+	'Å': "\\r{A}", 'Ç': "\\c{C}", 'È': "\\`E", 'É': "\\'E", 'Ê': "\\^E",
+	'Ë': "\\\"E", 'Ì': "\\`I", 'Í': "\\'I", 'Î': "\\^I", 'Ï': "\\\"I",
+	'Ñ': "\\~N", 'Ò': "\\`O", 'Ó': "\\'O", 'Ô': "\\^O", 'Õ': "\\~O",
+	'Ö': "\\\"O", 'Ù': "\\`U", 'Ú': "\\'U", 'Û': "\\^U", 'Ü': "\\\"U",
+	'Ý': "\\'Y", 'à': "\\`a", 'á': "\\'a", 'â': "\\^a", 'ã': "\\~a",
+	'ä': "\\\"a", 'å': "\\r{a}", 'ç': "\\c{c}", 'è': "\\`e", 'é': "\\'e",
+	'ê': "\\^e", 'ë': "\\\"e", 'ì': "\\`{\\i}", 'í': "\\'{\\i}",
+	'î': "\\^{\\i}", 'ï': "\\\"{\\i}", 'ñ': "\\~n", 'ò': "\\`o", 'ó': "\\'o",
+	'ô': "\\^o", 'õ': "\\~o", 'ö': "\\\"o", 'ù': "\\`u", 'ú': "\\'u",
+	'û': "\\^u", 'ü': "\\\"u", 'ý': "\\'y", 'ÿ': "\\\"y", 'Ā': "\\=A",
+	'ā': "\\=a", 'Ă': "\\u{A}", 'ă': "\\u{a}", 'Ą': "\\k{A}", 'ą': "\\k{a}",
+	'Ć': "\\'C", 'ć': "\\'c", 'Ĉ': "\\^C", 'ĉ': "\\^c", 'Ċ': "\\.C",
+	'ċ': "\\.c", 'Č': "\\v{C}", 'č': "\\v{c}", 'Ď': "\\v{D}", 'ď': "\\v{d}",
+	'Ē': "\\=E", 'ē': "\\=e", 'Ĕ': "\\u{E}", 'ĕ': "\\u{e}", 'Ė': "\\.E",
+	'ė': "\\.e", 'Ę': "\\k{E}", 'ę': "\\k{e}", 'Ě': "\\v{E}", 'ě': "\\v{e}",
+	'Ĝ': "\\^G", 'ĝ': "\\^g", 'Ğ': "\\u{G}", 'ğ': "\\u{g}", 'Ġ': "\\.G",
+	'ġ': "\\.g", 'Ģ': "\\c{G}", 'ģ': "\\c{g}", 'Ĥ': "\\^H", 'ĥ': "\\^h",
+	'Ĩ': "\\~I", 'ĩ': "\\~{\\i}", 'Ī': "\\=I", 'ī': "\\={\\i}", 'Ĭ': "\\u{I}",
+	'ĭ': "\\u{\\i}", 'Į': "\\k{I}", 'į': "\\k{\\i}", 'İ': "\\.I", 'Ĵ': "\\^J",
+	'ĵ': "\\^j", 'Ķ': "\\c{K}", 'ķ': "\\c{k}", 'Ĺ': "\\'L", 'ĺ': "\\'l",
+	'Ļ': "\\c{L}", 'ļ': "\\c{l}", 'Ľ': "\\v{L}", 'ľ': "\\v{l}", 'Ń': "\\'N",
+	'ń': "\\'n", 'Ņ': "\\c{N}", 'ņ': "\\c{n}", 'Ň': "\\v{N}", 'ň': "\\v{n}",
+	'Ō': "\\=O", 'ō': "\\=o", 'Ŏ': "\\u{O}", 'ŏ': "\\u{o}", 'Ő': "\\H{O}",
+	'ő': "\\H{o}", 'Ŕ': "\\'R", 'ŕ': "\\'r", 'Ŗ': "\\c{R}", 'ŗ': "\\c{r}",
+	'Ř': "\\v{R}", 'ř': "\\v{r}", 'Ś': "\\'S", 'ś': "\\'s", 'Ŝ': "\\^S",
+	'ŝ': "\\^s", 'Ş': "\\c{S}", 'ş': "\\c{s}", 'Š': "\\v{S}", 'š': "\\v{s}",
+	'Ţ': "\\c{T}", 'ţ': "\\c{t}", 'Ť': "\\v{T}", 'ť': "\\v{t}", 'Ũ': "\\~U",
+	'ũ': "\\~u", 'Ū': "\\=U", 'ū': "\\=u", 'Ŭ': "\\u{U}", 'ŭ': "\\u{u}",
+	'Ů': "\\r{U}", 'ů': "\\r{u}", 'Ű': "\\H{U}", 'ű': "\\H{u}", 'Ų': "\\k{U}",
+	'ų': "\\k{u}", 'Ŵ': "\\^W", 'ŵ': "\\^w", 'Ŷ': "\\^Y", 'ŷ': "\\^y",
+	'Ÿ': "\\\"Y", 'Ź': "\\'Z", 'ź': "\\'z", 'Ż': "\\.Z", 'ż': "\\.z",
+	'Ž': "\\v{Z}", 'ž': "\\v{z}", 'Ǎ': "\\v{A}", 'ǎ': "\\v{a}", 'Ǐ': "\\v{I}",
+	'ǐ': "\\v{\\i}", 'Ǒ': "\\v{O}", 'ǒ': "\\v{o}", 'Ǔ': "\\v{U}", 'ǔ': "\\v{u}",
+	'Ḃ': "\\.B", 'ḃ': "\\.b", 'Ḋ': "\\.D", 'ḋ': "\\.d", 'Ḑ': "\\c{D}",
+	'ḑ': "\\c{d}", 'Ḟ': "\\.F", 'ḟ': "\\.f", 'Ḡ': "\\=G", 'ḡ': "\\=g",
+	'Ḣ': "\\.H", 'ḣ': "\\.h", 'Ḧ': "\\\"H", 'ḧ': "\\\"h", 'Ḩ': "\\c{H}",
+	'ḩ': "\\c{h}", 'Ḱ': "\\'K", 'ḱ': "\\'k", 'Ḿ': "\\'M", 'ḿ': "\\'m",
+	'Ṁ': "\\.M", 'ṁ': "\\.m", 'Ṅ': "\\.N", 'ṅ': "\\.n", 'Ṕ': "\\'P",
+	'ṕ': "\\'p", 'Ṗ': "\\.P", 'ṗ': "\\.p", 'Ṙ': "\\.R", 'ṙ': "\\.r",
+	'Ṡ': "\\.S", 'ṡ': "\\.s", 'Ṫ': "\\.T", 'ṫ': "\\.t", 'Ṽ': "\\~V",
+	'ṽ': "\\~v", 'Ẁ': "\\`W", 'ẁ': "\\`w", 'Ẃ': "\\'W", 'ẃ': "\\'w",
+	'Ẅ': "\\\"W", 'ẅ': "\\\"w", 'Ẇ': "\\.W", 'ẇ': "\\.w", 'Ẋ': "\\.X",
+	'ẋ': "\\.x", 'Ẍ': "\\\"X", 'ẍ': "\\\"x", 'Ẏ': "\\.Y", 'ẏ': "\\.y",
+	'Ẑ': "\\^Z", 'ẑ': "\\^z", 'ẗ': "\\\"t", 'ẘ': "\\r{w}", 'ẙ': "\\r{y}",
+	'Ẽ': "\\~E", 'ẽ': "\\~e", 'Ỳ': "\\`Y", 'ỳ': "\\`y", 'Ỹ': "\\~Y",
+	'ỹ': "\\~y",
+}
+
+// latexASCII maps the accented and special letters to their closest ASCII
+// letters. It also has the letters without a LaTeX command.
+var latexASCII = func() map[rune]string {
+	m := map[rune]string{
+		'Ð': "D", 'ð': "d", 'Þ': "Th", 'þ': "th", 'Đ': "D", 'đ': "d",
+		'Ħ': "H", 'ħ': "h", 'Ĳ': "IJ", 'ĳ': "ij", 'ĸ': "k", 'Ŀ': "L",
+		'ŀ': "l", 'Ŋ': "N", 'ŋ': "n", 'Ŧ': "T", 'ŧ': "t", 'ſ': "s",
+		'Ș': "S", 'ș': "s", 'Ț': "T", 'ț': "t",
+	}
+	for r, l := range latexLetters {
+		m[r] = l.ascii
+	}
+	for r, tex := range latexAccents {
+		// the letter of \'e, \v{c}, or \'{\i}
+		m[r] = strings.TrimPrefix(strings.Trim(tex[2:], "{}"), `\`)
+	}
+	return m
+}()
+
+// latexDecoded maps an accent command and its letter, such as "'e" or "ci",
+// to the accented letter.
+var latexDecoded = func() map[string]rune {
+	m := make(map[string]rune, len(latexAccents))
+	for r, tex := range latexAccents {
+		// \'e, \v{c}, or \'{\i}
+		cmd, arg := tex[1:2], tex[2:]
+		arg = strings.Trim(arg, "{}")
+		m[cmd+strings.TrimPrefix(arg, `\`)] = r
+	}
+	return m
+}()
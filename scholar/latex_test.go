@@ -0,0 +1,133 @@
+package scholar
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestEscapeBib(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Salt & Pepper", `Salt \& Pepper`},
+		{`50% of snake_case #1`, `50\% of snake\_case \#1`},
+		{`Already \& escaped`, `Already \& escaped`},
+		{`The $x_1 & x_2$ case_a`, `The $x_1 & x_2$ case\_a`},
+		{`\(a_b\) and \[c_d\] and $$e_f$$`, `\(a_b\) and \[c_d\] and $$e_f$$`},
+		{`Costs \$5 & more`, `Costs \$5 \& more`},
+		{`Unclosed $math_`, `Unclosed $math\_`},
+	}
+	for _, tt := range tests {
+		if got := latexText(tt.in, escapeBib); got != tt.want {
+			t.Errorf("escapeBib(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeLaTeX(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Café", `Caf{\'e}`},
+		{"Gödel, Escher", `G{\"o}del, Escher`},
+		{"Čech–Straße", `{\v{C}}ech--Stra{\ss}e`},
+		{"naïve", `na{\"{\i}}ve`},
+		{"Ørsted “quoted”", "{\\O}rsted ``quoted''"},
+		{"$α$ é", `$α$ {\'e}`},
+		{"日本", "日本"},
+	}
+	for _, tt := range tests {
+		if got := latexText(tt.in, encodeLaTeX); got != tt.want {
+			t.Errorf("encodeLaTeX(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeLaTeX(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`Caf{\'e}`, "Café"},
+		{`Caf\'e`, "Café"},
+		{`Caf\'{e}`, "Café"},
+		{`{\v{C}}ech and \v Cech`, "Čech and Čech"},
+		{`na\"{\i}ve`, "naïve"},
+		{`Stra\ss e and {\ss}`, "Straße and ß"},
+		{`Salt \& Pepper 50\%`, "Salt & Pepper 50%"},
+		{`The {\TeX}book \emph{now}`, `The {\TeX}book \emph{now}`},
+		{`$\'e \&$ \'e`, `$\'e \&$ é`},
+		{`Costs \$5`, `Costs \$5`},
+	}
+	for _, tt := range tests {
+		if got := decodeLaTeX(tt.in); got != tt.want {
+			t.Errorf("decodeLaTeX(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExport_LaTeX(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["title"] = "Café & Bar: 100% of $x_1$"
+	entry.Optional["doi"] = "10.1000/a_b"
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"biblatex", []string{`title = {Café \& Bar: 100\% of $x_1$}`, `doi = {10.1000/a_b}`}},
+		{"bibtex", []string{`title = {Café \& Bar: 100\% of $x_1$}`, `doi = {10.1000/a_b}`}},
+	}
+	for _, tt := range tests {
+		got, err := entry.Export(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("Export(%q) = %s, want %s", tt.format, got, want)
+			}
+		}
+
+		entries, err := Import(strings.NewReader(got), tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title := entries[0].field("title"); title != entry.Required["title"] {
+			t.Errorf("Import(Export(%q)) title = %q, want %q", tt.format, title, entry.Required["title"])
+		}
+	}
+	t.Run("ascii option", func(t *testing.T) {
+		for _, format := range []string{"bibtex", "biblatex"} {
+			b := new(strings.Builder)
+			ew, err := NewExportWriter(b, format, ASCII())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ew.Write(entry); err != nil {
+				t.Fatal(err)
+			}
+			if err := ew.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if want := `title = {Caf{\'e} \& Bar: 100\% of $x_1$}`; !strings.Contains(b.String(), want) {
+				t.Errorf("Export(%q, ASCII()) = %s, want %s", format, b, want)
+			}
+
+			entries, err := Import(strings.NewReader(b.String()), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if title := entries[0].field("title"); title != entry.Required["title"] {
+				t.Errorf("Import(Export(%q, ASCII())) title = %q, want %q", format, title, entry.Required["title"])
+			}
+		}
+
+		if _, err := NewExportWriter(ioutil.Discard, "ris", ASCII()); !IsError(ErrFormatNotFound, err) {
+			t.Errorf("NewExportWriter(ris, ASCII()) error = %v, want ErrFormatNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+package scholar
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// EntryFile is the name of the file that holds the metadata of an entry
+// inside its directory.
+const EntryFile = "entry.yaml"
+
+// Library is a collection of entries stored on disk. Each entry is saved in
+// its own directory, named after the key of the entry, with the following
+// layout:
+//
+//	<path>/<key>/entry.yaml
+//	<path>/<key>/.history/<previous versions>
+//	<path>/<key>/<attached files>
+type Library struct {
+	Path string
+}
+
+// Open returns the library located at path. The directory does not need to
+// exist, it is created the first time an entry is saved. If path exists but it
+// is not a directory, Open returns an error.
+func Open(path string) (*Library, error) {
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, getError("Open", ErrLibraryNotFound, err)
+	}
+	if err == nil && !info.IsDir() {
+		return nil, getError("Open", ErrLibraryNotFound, nil).
+			info(fmt.Sprintf("%q is not a directory", path))
+	}
+
+	return &Library{Path: path}, nil
+}
+
+// Dir returns the directory of the entry with the given key. The directory is
+// always inside the library: only the last element of a key with path
+// separators or ".." is used. Such keys are rejected by the other methods.
+func (l *Library) Dir(key string) string {
+	if checkKey("Dir", key) != nil {
+		key = filepath.Base(filepath.Clean(string(filepath.Separator) + filepath.FromSlash(key)))
+	}
+	return filepath.Join(l.Path, key)
+}
+
+// Exists checks if there is an entry with the given key in the library.
+func (l *Library) Exists(key string) bool {
+	if checkKey("Exists", key) != nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(l.Dir(key), EntryFile))
+	return err == nil
+}
+
+// UniqueKey returns a key based on key that is not used by any directory of
+// the library. If key is already taken, a letter is appended to it.
+// For example: einstein1922 -> einstein1922a -> einstein1922b
+func (l *Library) UniqueKey(key string) string {
+	mark := 'a'
+	valid := key
+
+	for _, err := os.Stat(l.Dir(valid)); !os.IsNotExist(err); _, err = os.Stat(l.Dir(valid)) {
+		valid = fmt.Sprintf("%s%s", key, string(mark))
+		mark++
+	}
+
+	return valid
+}
+
+// checkKey returns an error if key cannot be used as the name of the
+// directory of an entry, so entries are never read or written outside the
+// library.
+func checkKey(op errorOp, key string) error {
+	if key == "" || key == "." || key == ".." ||
+		strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+		return getError(op, ErrInvalidEntry, nil).
+			info(fmt.Sprintf("%q is not a valid key", key))
+	}
+	return nil
+}
+
+// List returns all the entries of the library sorted by key. Directories
+// without an entry file are ignored. List does not change the library: if the
+// key of an entry does not match the name of its directory (for example,
+// after the entry file was edited by hand), the entry is returned with the key
+// of its file until Fix is called.
+func (l *Library) List() ([]*Entry, error) {
+	entries, _, err := l.list("List")
+	return entries, err
+}
+
+// Fix renames the directories of the entries whose key does not match the
+// name of their directory (see Rename). It returns the renamed entries.
+func (l *Library) Fix() ([]*Entry, error) {
+	_, moved, err := l.list("Fix")
+	if err != nil {
+		return nil, err
+	}
+
+	// Renaming is done sequentially to avoid key collisions
+	dirs := make([]string, 0, len(moved))
+	for dir := range moved {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var renamed []*Entry
+	for _, dir := range dirs {
+		e, err := l.Rename(dir, moved[dir])
+		if err != nil {
+			return nil, getError("Fix", errNotDefined, err)
+		}
+		renamed = append(renamed, e)
+	}
+
+	return renamed, nil
+}
+
+// list returns the entries of the library, and the directories whose name
+// does not match the key of their entry.
+func (l *Library) list(op errorOp) ([]*Entry, map[string]string, error) {
+	dirs, err := ioutil.ReadDir(l.Path)
+	if err != nil {
+		return nil, nil, getError(op, ErrLibraryNotFound, err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var listError error
+
+	entries := []*Entry{}
+	moved := make(map[string]string)
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		dir := dir
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e, err := l.Get(dir.Name())
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !IsError(ErrEntryNotFound, err) {
+					listError = getError(op, errNotDefined, err)
+				}
+				return
+			}
+			if e.GetKey() != dir.Name() {
+				moved[dir.Name()] = e.Key
+			}
+			entries = append(entries, e)
+		}()
+	}
+	wg.Wait()
+
+	if listError != nil {
+		return nil, nil, listError
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, moved, nil
+}
+
+// Get loads the entry saved under the directory key. The key of the returned
+// entry is the one written in the entry file, which can differ from the
+// directory name if the file was edited by hand. Keys with path separators or
+// ".." are rejected with an ErrInvalidEntry error.
+func (l *Library) Get(key string) (*Entry, error) {
+	if err := checkKey("Get", key); err != nil {
+		return nil, err
+	}
+	file := filepath.Join(l.Dir(key), EntryFile)
+
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, getError("Get", ErrEntryNotFound, err).
+			info(fmt.Sprintf("no entry with key %q", key))
+	}
+
+	var e Entry
+	if err := yaml.Unmarshal(d, &e); err != nil {
+		return nil, getError("Get", ErrParse, err).
+			info(fmt.Sprintf("could not read %s", file))
+	}
+
+	if e.Info, err = os.Stat(file); err != nil {
+		return nil, getError("Get", ErrIO, err)
+	}
+
+	return &e, nil
+}
+
+// Put saves the entry in the directory named after its key. Keys with path
+// separators or ".." are rejected with an ErrInvalidEntry error. If the directory
+// does not exist, it is created. If the entry already exists and it is
+// changed, its previous version is saved in the history (see History).
+func (l *Library) Put(e *Entry) error {
+	key := e.GetKey()
+	if err := checkKey("Put", key); err != nil {
+		return err
+	}
+	dir := l.Dir(key)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return getError("Put", ErrIO, err)
+	}
+
+	d, err := yaml.Marshal(e)
+	if err != nil {
+		return getError("Put", errNotDefined, err)
+	}
+
+	file := filepath.Join(dir, EntryFile)
+	if old, err := ioutil.ReadFile(file); err == nil && !bytes.Equal(old, d) {
+		if err := l.saveRevision(key, old); err != nil {
+			return getError("Put", ErrIO, err)
+		}
+	}
+	if err := ioutil.WriteFile(file, d, 0644); err != nil {
+		return getError("Put", ErrIO, err)
+	}
+
+	if e.Info, err = os.Stat(file); err != nil {
+		return getError("Put", ErrIO, err)
+	}
+
+	return nil
+}
+
+// Delete removes the entry with the given key, including all its attached
+// files.
+func (l *Library) Delete(key string) error {
+	if err := checkKey("Delete", key); err != nil {
+		return err
+	}
+	if !l.Exists(key) {
+		return getError("Delete", ErrEntryNotFound, nil).
+			info(fmt.Sprintf("no entry with key %q", key))
+	}
+	if err := os.RemoveAll(l.Dir(key)); err != nil {
+		return getError("Delete", ErrIO, err)
+	}
+
+	return nil
+}
+
+// Rename moves the entry stored under oldKey to newKey and returns the updated
+// entry. If newKey is already taken, a unique key is generated from it (see
+// UniqueKey), so the key of the returned entry can differ from newKey.
+func (l *Library) Rename(oldKey, newKey string) (*Entry, error) {
+	for _, key := range []string{oldKey, newKey} {
+		if err := checkKey("Rename", key); err != nil {
+			return nil, err
+		}
+	}
+	e, err := l.Get(oldKey)
+	if err != nil {
+		return nil, getError("Rename", errNotDefined, err)
+	}
+	if oldKey == newKey {
+		return e, nil
+	}
+
+	e.Key = l.UniqueKey(newKey)
+	if err := os.Rename(l.Dir(oldKey), l.Dir(e.Key)); err != nil {
+		return nil, getError("Rename", ErrIO, err)
+	}
+	if err := l.Put(e); err != nil {
+		return nil, getError("Rename", errNotDefined, err)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,275 @@
+package scholar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mockLibrary(t *testing.T) *Library {
+	l, err := Open(filepath.Join(t.TempDir(), "library"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("missing directory", func(t *testing.T) {
+		if _, err := Open(filepath.Join(t.TempDir(), "missing")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("not a directory", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		if err := ioutil.WriteFile(file, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Open(file)
+		if !IsError(ErrLibraryNotFound, err) {
+			t.Fatal("error other than ErrLibraryNotFound:", err)
+		}
+		t.Log("Expected error:\n", err)
+	})
+}
+
+func TestLibrary_PutGet(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Exists("last2006") {
+		t.Fatalf("entry %q was not saved", "last2006")
+	}
+
+	got, err := l.Get("last2006")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Info == nil {
+		t.Error("Get() did not set the file information")
+	}
+	if got.Bib() != entry.Bib() {
+		t.Errorf("Get() did not return the saved entry:\ngot:\n%v\nwant:\n%v", got.Bib(), entry.Bib())
+	}
+
+	t.Run("test ErrEntryNotFound", func(t *testing.T) {
+		_, err := l.Get("missing")
+		if !IsError(ErrEntryNotFound, err) {
+			t.Fatal("error other than ErrEntryNotFound:", err)
+		}
+		t.Log("Expected error:\n", err)
+	})
+}
+
+func TestLibrary_List(t *testing.T) {
+	l := mockLibrary(t)
+
+	t.Run("test ErrLibraryNotFound", func(t *testing.T) {
+		_, err := l.List()
+		if !IsError(ErrLibraryNotFound, err) {
+			t.Fatal("error other than ErrLibraryNotFound:", err)
+		}
+		t.Log("Expected error:\n", err)
+	})
+
+	want := []string{"c2000", "a2001", "b2002"}
+	for _, key := range want {
+		entry, err := mockEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Key = key
+		if err := l.Put(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directories without an entry file are ignored
+	if err := os.Mkdir(l.Dir("empty"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := l.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("List() returned %d entries, want %d", len(entries), len(want))
+	}
+	for i, key := range []string{"a2001", "b2002", "c2000"} {
+		if entries[i].Key != key {
+			t.Errorf("List()[%d].Key = %q, want %q", i, entries[i].Key, key)
+		}
+	}
+}
+
+func TestLibrary_UniqueKey(t *testing.T) {
+	l := mockLibrary(t)
+
+	want := []string{"last2006", "last2006a", "last2006b"}
+	for _, key := range want {
+		entry, err := mockEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Key = l.UniqueKey(entry.GetKey())
+		if entry.Key != key {
+			t.Errorf("UniqueKey() = %q, want %q", entry.Key, key)
+		}
+		if err := l.Put(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLibrary_Rename(t *testing.T) {
+	l := mockLibrary(t)
+	for _, key := range []string{"old", "new"} {
+		entry, err := mockEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Key = key
+		if err := l.Put(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, err := l.Rename("old", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Key != "newa" {
+		t.Errorf("renamed entry.Key = %q, want %q", e.Key, "newa")
+	}
+	if l.Exists("old") {
+		t.Errorf("entry %q was not moved", "old")
+	}
+
+	got, err := l.Get("newa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Key != "newa" {
+		t.Errorf("saved entry.Key = %q, want %q", got.Key, "newa")
+	}
+}
+
+func TestLibrary_Delete(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Delete(entry.Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(l.Dir(entry.Key)); !os.IsNotExist(err) {
+		t.Errorf("directory of entry %q was not removed", entry.Key)
+	}
+
+	t.Run("test ErrEntryNotFound", func(t *testing.T) {
+		err := l.Delete(entry.Key)
+		if !IsError(ErrEntryNotFound, err) {
+			t.Fatal("error other than ErrEntryNotFound:", err)
+		}
+		t.Log("Expected error:\n", err)
+	})
+}
+
+func TestLibrary_Fix(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit the key by hand
+	file := filepath.Join(l.Dir("last2006"), EntryFile)
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = []byte(strings.Replace(string(d), "key: last2006", "key: edited", 1))
+	if err := ioutil.WriteFile(file, d, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := l.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != "edited" {
+		t.Fatalf("List() = %v, want entry %q", entries, "edited")
+	}
+	if !l.Exists("last2006") || l.Exists("edited") {
+		t.Fatal("List() renamed the directory of the entry")
+	}
+
+	renamed, err := l.Fix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 1 || renamed[0].Key != "edited" {
+		t.Fatalf("Fix() = %v, want entry %q", renamed, "edited")
+	}
+	if l.Exists("last2006") || !l.Exists("edited") {
+		t.Error("Fix() did not rename the directory of the entry")
+	}
+}
+
+func TestLibrary_InvalidKey(t *testing.T) {
+	l := mockLibrary(t)
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry outside the library, next to its directory
+	outside := filepath.Join(filepath.Dir(l.Path), "x")
+	if err := os.MkdirAll(outside, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(outside, EntryFile), []byte("type: article\nkey: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Get("../x"); !IsError(ErrInvalidEntry, err) {
+		t.Error("Get: error other than ErrInvalidEntry:", err)
+	}
+	if l.Exists("../x") {
+		t.Error("Exists: found an entry outside the library")
+	}
+
+	for _, key := range []string{"../outside", "a/b", `a\b`, "..", "."} {
+		t.Run(key, func(t *testing.T) {
+			if dir, err := filepath.Rel(l.Path, l.Dir(key)); err != nil || strings.HasPrefix(dir, "..") {
+				t.Errorf("Dir: %s is outside the library", l.Dir(key))
+			}
+			entry.Key = key
+			if err := l.Put(entry); !IsError(ErrInvalidEntry, err) {
+				t.Error("Put: error other than ErrInvalidEntry:", err)
+			}
+			if err := l.Delete(key); !IsError(ErrInvalidEntry, err) {
+				t.Error("Delete: error other than ErrInvalidEntry:", err)
+			}
+			if _, err := l.Rename("last2006", key); !IsError(ErrInvalidEntry, err) {
+				t.Error("Rename: error other than ErrInvalidEntry:", err)
+			}
+		})
+	}
+}
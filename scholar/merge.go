@@ -0,0 +1,127 @@
+package scholar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MergePolicy tells Merge which value to keep when two entries have different
+// values for the same field.
+type MergePolicy uint8
+
+const (
+	// KeepFirst keeps the value of the first entry.
+	KeepFirst MergePolicy = iota
+	// KeepSecond keeps the value of the second entry.
+	KeepSecond
+	// KeepLonger keeps the longer value. If both values have the same
+	// length, the value of the first entry is kept.
+	KeepLonger
+)
+
+func (p MergePolicy) pick(first, second string) string {
+	switch p {
+	case KeepSecond:
+		return second
+	case KeepLonger:
+		if utf8.RuneCountInString(second) > utf8.RuneCountInString(first) {
+			return second
+		}
+	}
+	return first
+}
+
+// Conflict is a field with different values in two merged entries. Value is
+// the value kept by the merge policy.
+type Conflict struct {
+	Field  string
+	First  string
+	Second string
+	Value  string
+}
+
+// String implements the Stringer interface.
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %q or %q (kept %q)", c.Field, c.First, c.Second, c.Value)
+}
+
+// Merge combines two entries into a new entry with the type and key of a. Both
+// entries are converted to the type of a first (see Convert), so aliases, such
+// as journal, are compared with the fields they alias. Entries of a type that
+// is not loaded are compared as they are.
+//
+// Fields that are empty in one entry take the value of the other. Fields with
+// different values are resolved with policy and returned as conflicts, sorted
+// by field. Files attached to b are added after the files of a, unless a
+// already has a file with the same path.
+func Merge(a, b *Entry, policy MergePolicy) (*Entry, []Conflict, error) {
+	if _, ok := EntryTypes[a.Type]; ok || b.Type != a.Type {
+		var err error
+		if a, err = Convert(a, a.Type); err != nil && !IsError(ErrFieldNotFound, err) {
+			return nil, nil, getError("Merge", errNotDefined, err)
+		}
+		if b, err = Convert(b, a.Type); err != nil && !IsError(ErrFieldNotFound, err) {
+			return nil, nil, getError("Merge", errNotDefined, err)
+		}
+	}
+
+	m := &Entry{
+		Type:     a.Type,
+		Key:      a.Key,
+		Required: make(map[string]string),
+		Optional: make(map[string]string),
+	}
+
+	fields := make(map[string]bool)
+	for _, from := range []map[string]string{a.Required, a.Optional, b.Required, b.Optional} {
+		for field := range from {
+			fields[field] = true
+		}
+	}
+
+	var conflicts []Conflict
+	for field := range fields {
+		first := strings.TrimSpace(a.field(field))
+		second := strings.TrimSpace(b.field(field))
+
+		value := first
+		switch {
+		case first == "":
+			value = second
+		case second != "" && first != second:
+			value = policy.pick(first, second)
+			conflicts = append(conflicts, Conflict{
+				Field:  field,
+				First:  first,
+				Second: second,
+				Value:  value,
+			})
+		}
+
+		_, reqA := a.Required[field]
+		_, reqB := b.Required[field]
+		if reqA || reqB {
+			m.Required[field] = value
+		} else if value != "" {
+			m.Optional[field] = value
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Field < conflicts[j].Field
+	})
+
+	paths := make(map[string]bool)
+	for _, f := range a.Files {
+		m.Files = append(m.Files, f)
+		paths[f.Path] = true
+	}
+	for _, f := range b.Files {
+		if !paths[f.Path] {
+			m.Files = append(m.Files, f)
+		}
+	}
+
+	return m, conflicts, nil
+}
@@ -0,0 +1,128 @@
+package scholar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Required["journaltitle"] = ""
+	a.Files = []Attachment{{Path: "paper.pdf"}}
+
+	b, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Key = "other2006"
+	b.Required["title"] = "The Longer Title"
+	b.Optional["doi"] = "123/456789"
+	b.Optional["note"] = "A note"
+	b.Files = []Attachment{{Path: "paper.pdf", Role: "preprint"}, {Path: "slides.pdf", Role: "slides"}}
+
+	tests := []struct {
+		policy MergePolicy
+		title  string
+	}{
+		{KeepFirst, "The Title"},
+		{KeepSecond, "The Longer Title"},
+		{KeepLonger, "The Longer Title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			m, conflicts, err := Merge(a, b, tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.Key != a.Key || m.Type != a.Type {
+				t.Errorf("got %s{%s}, want %s{%s}", m.Type, m.Key, a.Type, a.Key)
+			}
+			if got := m.Required["title"]; got != tt.title {
+				t.Errorf("title: got %q, want %q", got, tt.title)
+			}
+			if got := m.Required["journaltitle"]; got != "The Journal" {
+				t.Errorf("journaltitle: got %q, want %q", got, "The Journal")
+			}
+			if got := m.Optional["note"]; got != "A note" {
+				t.Errorf("note: got %q, want %q", got, "A note")
+			}
+
+			want := []Conflict{{Field: "title", First: "The Title", Second: "The Longer Title", Value: tt.title}}
+			if !reflect.DeepEqual(conflicts, want) {
+				t.Errorf("conflicts:\ngot:  %v\nwant: %v", conflicts, want)
+			}
+
+			files := []Attachment{{Path: "paper.pdf"}, {Path: "slides.pdf", Role: "slides"}}
+			if !reflect.DeepEqual(m.Files, files) {
+				t.Errorf("files:\ngot:  %v\nwant: %v", m.Files, files)
+			}
+		})
+	}
+
+	t.Run("different types", func(t *testing.T) {
+		book, err := NewEntry("book")
+		if err != nil {
+			t.Fatal(err)
+		}
+		book.Required["title"] = "The Title"
+		book.Optional["isbn"] = "978-3-16-148410-0"
+
+		m, conflicts, err := Merge(a, book, KeepFirst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(conflicts) != 0 {
+			t.Errorf("unexpected conflicts: %v", conflicts)
+		}
+		if m.Type != "article" {
+			t.Errorf("type: got %q, want %q", m.Type, "article")
+		}
+		if got := m.Optional["isbn"]; got != "978-3-16-148410-0" {
+			t.Errorf("isbn: got %q, want %q", got, "978-3-16-148410-0")
+		}
+		if got := m.Required["author"]; got != a.Required["author"] {
+			t.Errorf("author: got %q, want %q", got, a.Required["author"])
+		}
+	})
+}
+
+func TestMerge_Aliases(t *testing.T) {
+	if err := loadTypes(mockInheritedTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewEntry("article")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Required["title"] = "The Title"
+	a.Optional["journal"] = "Old"
+
+	b, err := NewEntry("article")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Required["title"] = "The Title"
+	b.Required["journaltitle"] = "New"
+
+	m, conflicts, err := Merge(a, b, KeepSecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Conflict{{Field: "journaltitle", First: "Old", Second: "New", Value: "New"}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("conflicts:\ngot:  %v\nwant: %v", conflicts, want)
+	}
+	if got := m.Required["journaltitle"]; got != "New" {
+		t.Errorf("journaltitle: got %q, want %q", got, "New")
+	}
+	if _, ok := m.Optional["journal"]; ok {
+		t.Errorf("alias journal was kept: %v", m.Optional)
+	}
+}
@@ -0,0 +1,429 @@
+package scholar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	modsNamespace = "http://www.loc.gov/mods/v3"
+	modsSchema    = "http://www.loc.gov/standards/mods/v3/mods-3-7.xsd"
+	modsVersion   = "3.7"
+)
+
+type modsText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type modsTitleInfo struct {
+	Type     string `xml:"type,attr,omitempty"`
+	Title    string `xml:"title"`
+	SubTitle string `xml:"subTitle,omitempty"`
+}
+
+type modsRoleTerm struct {
+	Authority string `xml:"authority,attr"`
+	Type      string `xml:"type,attr"`
+	Value     string `xml:",chardata"`
+}
+
+type modsName struct {
+	Type  string       `xml:"type,attr"`
+	Parts []modsText   `xml:"namePart"`
+	Role  modsRoleTerm `xml:"role>roleTerm"`
+}
+
+type modsGenre struct {
+	Authority string `xml:"authority,attr"`
+	Value     string `xml:",chardata"`
+}
+
+type modsDate struct {
+	Encoding string `xml:"encoding,attr"`
+	Point    string `xml:"point,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type modsPlace struct {
+	Term modsText `xml:"placeTerm"`
+}
+
+type modsOriginInfo struct {
+	Places       []modsPlace `xml:"place"`
+	Publishers   []string    `xml:"publisher"`
+	DateIssued   []modsDate  `xml:"dateIssued"`
+	DateCaptured []modsDate  `xml:"dateCaptured"`
+	Edition      string      `xml:"edition,omitempty"`
+	Issuance     string      `xml:"issuance,omitempty"`
+}
+
+type modsDetail struct {
+	Type   string `xml:"type,attr"`
+	Number string `xml:"number"`
+}
+
+type modsExtent struct {
+	Unit  string `xml:"unit,attr"`
+	Start string `xml:"start,omitempty"`
+	End   string `xml:"end,omitempty"`
+	Total string `xml:"total,omitempty"`
+	List  string `xml:"list,omitempty"`
+}
+
+type modsPart struct {
+	Details []modsDetail `xml:"detail"`
+	Extents []modsExtent `xml:"extent"`
+}
+
+type modsURL struct {
+	DateLastAccessed string `xml:"dateLastAccessed,attr,omitempty"`
+	Value            string `xml:",chardata"`
+}
+
+type modsLanguage struct {
+	Terms []modsText `xml:"languageTerm"`
+}
+
+type modsLocation struct {
+	URLs []modsURL `xml:"url"`
+}
+
+type modsRecordInfo struct {
+	ID string `xml:"recordIdentifier"`
+}
+
+type modsSubject struct {
+	Topic string `xml:"topic"`
+}
+
+type modsRelatedItem struct {
+	Type        string          `xml:"type,attr"`
+	TitleInfo   []modsTitleInfo `xml:"titleInfo"`
+	Names       []modsName      `xml:"name"`
+	Genres      []modsGenre     `xml:"genre"`
+	OriginInfo  *modsOriginInfo `xml:"originInfo,omitempty"`
+	Identifiers []modsText      `xml:"identifier"`
+	Part        *modsPart       `xml:"part,omitempty"`
+}
+
+type modsRecord struct {
+	XMLName        xml.Name
+	Version        string             `xml:"version,attr"`
+	TitleInfo      []modsTitleInfo    `xml:"titleInfo"`
+	Names          []modsName         `xml:"name"`
+	TypeOfResource string             `xml:"typeOfResource"`
+	Genres         []modsGenre        `xml:"genre"`
+	OriginInfo     *modsOriginInfo    `xml:"originInfo,omitempty"`
+	Language       *modsLanguage      `xml:"language,omitempty"`
+	Abstract       string             `xml:"abstract,omitempty"`
+	Notes          []string           `xml:"note"`
+	Subjects       []modsSubject      `xml:"subject"`
+	RelatedItems   []*modsRelatedItem `xml:"relatedItem"`
+	Identifiers    []modsText         `xml:"identifier"`
+	Location       *modsLocation      `xml:"location,omitempty"`
+	Part           *modsPart          `xml:"part,omitempty"`
+	RecordInfo     *modsRecordInfo    `xml:"recordInfo,omitempty"`
+}
+
+type exMODS struct {
+	dict  map[string]string
+	types map[string]modsGenre
+	hosts map[string]modsGenre
+}
+
+// newMODSName returns the name split in name parts. Names enclosed in braces
+// are written as corporate names.
+func newMODSName(n Name, role string) modsName {
+	name := modsName{
+		Type: "personal",
+		Role: modsRoleTerm{Authority: "marcrelator", Type: "text", Value: role},
+	}
+	if n.First == "" && n.Von == "" && n.Jr == "" && strings.HasPrefix(n.Last, "{") {
+		name.Type = "corporate"
+		name.Parts = []modsText{{Value: unbrace(n.Last)}}
+		return name
+	}
+	if n.First != "" {
+		name.Parts = append(name.Parts, modsText{Type: "given", Value: unbrace(n.First)})
+	}
+	name.Parts = append(name.Parts, modsText{Type: "family", Value: unbrace(strings.TrimSpace(n.Von + " " + n.Last))})
+	if n.Jr != "" {
+		name.Parts = append(name.Parts, modsText{Type: "termsOfAddress", Value: unbrace(n.Jr)})
+	}
+	return name
+}
+
+func newMODSDates(value string) []modsDate {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return []modsDate{{Encoding: "w3cdtf", Value: value}}
+	}
+	var dates []modsDate
+	if parts[0] != "" {
+		dates = append(dates, modsDate{Encoding: "w3cdtf", Point: "start", Value: parts[0]})
+	}
+	if parts[1] != "" {
+		dates = append(dates, modsDate{Encoding: "w3cdtf", Point: "end", Value: parts[1]})
+	}
+	return dates
+}
+
+// record returns the MODS record of the entry. Titles of journals and books
+// that contain the entry are written in a host related item, together with
+// the volume, issue, and pages.
+func (ex *exMODS) record(e *Entry) *modsRecord {
+	rec := &modsRecord{
+		XMLName:        xml.Name{Local: "mods"},
+		Version:        modsVersion,
+		TypeOfResource: "text",
+	}
+	if key := e.GetKey(); key != "" {
+		rec.RecordInfo = &modsRecordInfo{ID: key}
+	}
+	if g, ok := ex.types[e.Type]; ok {
+		rec.Genres = append(rec.Genres, g)
+	}
+	switch e.Type {
+	case "software", "dataset":
+		rec.TypeOfResource = "software, multimedia"
+	}
+
+	var host *modsRelatedItem
+	if e.field("journaltitle") != "" || e.field("booktitle") != "" {
+		host = &modsRelatedItem{Type: "host"}
+		if g, ok := ex.hosts[e.Type]; ok {
+			host.Genres = append(host.Genres, g)
+		}
+		if e.field("journaltitle") != "" {
+			host.OriginInfo = &modsOriginInfo{Issuance: "continuing"}
+		}
+		rec.RelatedItems = append(rec.RelatedItems, host)
+	}
+	inBook := host != nil && e.field("booktitle") != ""
+
+	origin := func() *modsOriginInfo {
+		if rec.OriginInfo == nil {
+			rec.OriginInfo = &modsOriginInfo{}
+		}
+		return rec.OriginInfo
+	}
+	part := func() *modsPart {
+		if host != nil {
+			if host.Part == nil {
+				host.Part = &modsPart{}
+			}
+			return host.Part
+		}
+		if rec.Part == nil {
+			rec.Part = &modsPart{}
+		}
+		return rec.Part
+	}
+
+	// <element>value</element>
+	write := func(field, value string) {
+		switch kind := ex.dict[field]; kind {
+		case "name":
+			names := &rec.Names
+			if field == "editor" && inBook {
+				names = &host.Names
+			}
+			for _, n := range ParseNames(value) {
+				if !n.Others() {
+					*names = append(*names, newMODSName(n, field))
+				}
+			}
+		case "title":
+			if len(rec.TitleInfo) == 0 || rec.TitleInfo[0].Type != "" {
+				rec.TitleInfo = append([]modsTitleInfo{{}}, rec.TitleInfo...)
+			}
+			rec.TitleInfo[0].Title = value
+		case "subTitle":
+			if len(rec.TitleInfo) == 0 || rec.TitleInfo[0].Type != "" {
+				rec.TitleInfo = append([]modsTitleInfo{{}}, rec.TitleInfo...)
+			}
+			rec.TitleInfo[0].SubTitle = value
+		case "abbreviated":
+			rec.TitleInfo = append(rec.TitleInfo, modsTitleInfo{Type: kind, Title: value})
+		case "host":
+			host.TitleInfo = append(host.TitleInfo, modsTitleInfo{Title: value})
+		case "series":
+			rec.RelatedItems = append(rec.RelatedItems, &modsRelatedItem{
+				Type:      kind,
+				TitleInfo: []modsTitleInfo{{Title: value}},
+			})
+		case "dateIssued":
+			origin().DateIssued = newMODSDates(value)
+		case "dateCaptured":
+			if rec.Location != nil {
+				rec.Location.URLs[0].DateLastAccessed = value
+			} else {
+				origin().DateCaptured = newMODSDates(value)
+			}
+		case "publisher":
+			for _, p := range e.Literals(field) {
+				origin().Publishers = append(origin().Publishers, unbrace(p))
+			}
+		case "place":
+			for _, p := range e.Literals(field) {
+				origin().Places = append(origin().Places, modsPlace{modsText{Type: "text", Value: unbrace(p)}})
+			}
+		case "edition":
+			origin().Edition = value
+		case "volume":
+			part().Details = append(part().Details, modsDetail{Type: kind, Number: value})
+		case "number":
+			if e.field("journaltitle") != "" {
+				kind = "issue"
+			}
+			part().Details = append(part().Details, modsDetail{Type: kind, Number: value})
+		case "pages":
+			ranges, err := ParseRanges(value)
+			if err != nil || len(ranges) > 1 {
+				part().Extents = append(part().Extents, modsExtent{Unit: "pages", List: value})
+				return
+			}
+			part().Extents = append(part().Extents, modsExtent{Unit: "pages", Start: ranges[0].Start, End: ranges[0].End})
+		case "pagetotal":
+			if rec.Part == nil {
+				rec.Part = &modsPart{}
+			}
+			rec.Part.Extents = append(rec.Part.Extents, modsExtent{Unit: "pages", Total: value})
+		case "doi", "isbn", "issn":
+			id := modsText{Type: kind, Value: value}
+			if kind == "issn" && host != nil || kind == "isbn" && inBook {
+				host.Identifiers = append(host.Identifiers, id)
+				return
+			}
+			rec.Identifiers = append(rec.Identifiers, id)
+		case "url":
+			if rec.Location == nil {
+				rec.Location = &modsLocation{}
+			}
+			rec.Location.URLs = append(rec.Location.URLs, modsURL{Value: value})
+		case "abstract":
+			rec.Abstract = value
+		case "note":
+			rec.Notes = append(rec.Notes, value)
+		case "subject":
+			for _, kw := range strings.Split(value, ",") {
+				if kw = strings.TrimSpace(kw); kw != "" {
+					rec.Subjects = append(rec.Subjects, modsSubject{kw})
+				}
+			}
+		case "language":
+			if rec.Language == nil {
+				rec.Language = &modsLanguage{}
+			}
+			rec.Language.Terms = append(rec.Language.Terms, modsText{Type: "text", Value: value})
+		}
+	}
+
+	fields := make([]string, 0, len(e.Required))
+	for field := range e.Required {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := strings.TrimSpace(e.Required[field]); value != "" {
+			write(field, value)
+		}
+	}
+
+	fields = fields[:0]
+	for field := range e.Optional {
+		if _, ok := e.Required[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := strings.TrimSpace(e.Optional[field]); value != "" {
+			write(field, value)
+		}
+	}
+
+	return rec
+}
+
+// Begin implements the Exporter interface. The entries are written as a
+// MODS collection.
+func (ex *exMODS) Begin(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s<modsCollection xmlns=%q xmlns:xsi=%q xsi:schemaLocation=%q>",
+		xml.Header, modsNamespace, "http://www.w3.org/2001/XMLSchema-instance",
+		modsNamespace+" "+modsSchema)
+	return err
+}
+
+// Entry implements the Exporter interface.
+func (ex *exMODS) Entry(w io.Writer, e *Entry, i int) error {
+	d, err := xml.MarshalIndent(ex.record(e), "  ", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\n%s", d)
+	return err
+}
+
+// End implements the Exporter interface.
+func (ex *exMODS) End(w io.Writer) error {
+	_, err := io.WriteString(w, "\n</modsCollection>\n")
+	return err
+}
+
+var mods = &exMODS{
+	dict: map[string]string{
+		"author":       "name",
+		"editor":       "name",
+		"translator":   "name",
+		"title":        "title",
+		"subtitle":     "subTitle",
+		"shorttitle":   "abbreviated",
+		"journaltitle": "host",
+		"booktitle":    "host",
+		"series":       "series",
+		"date":         "dateIssued",
+		"urldate":      "dateCaptured",
+		"publisher":    "publisher",
+		"institution":  "publisher",
+		"organization": "publisher",
+		"location":     "place",
+		"edition":      "edition",
+		"volume":       "volume",
+		"number":       "number",
+		"pages":        "pages",
+		"pagetotal":    "pagetotal",
+		"doi":          "doi",
+		"isbn":         "isbn",
+		"issn":         "issn",
+		"url":          "url",
+		"abstract":     "abstract",
+		"note":         "note",
+		"keywords":     "subject",
+		"language":     "language",
+	},
+	types: map[string]modsGenre{
+		"book":          {"marcgt", "book"},
+		"mvbook":        {"marcgt", "book"},
+		"collection":    {"marcgt", "book"},
+		"proceedings":   {"marcgt", "conference publication"},
+		"inproceedings": {"marcgt", "conference publication"},
+		"report":        {"marcgt", "technical report"},
+		"manual":        {"marcgt", "instruction"},
+		"thesis":        {"marcgt", "thesis"},
+		"online":        {"marcgt", "web site"},
+		"patent":        {"marcgt", "patent"},
+		"software":      {"marcgt", "computer program"},
+		"dataset":       {"marcgt", "database"},
+	},
+	hosts: map[string]modsGenre{
+		"article":       {"marcgt", "periodical"},
+		"inbook":        {"marcgt", "book"},
+		"incollection":  {"marcgt", "book"},
+		"inproceedings": {"marcgt", "conference publication"},
+	},
+}
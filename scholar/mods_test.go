@@ -0,0 +1,106 @@
+package scholar
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestExMODS_ExportList(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["author"] = "von Last, Jr., First and {World Health Organization} and others"
+	entry.Optional["volume"] = "3"
+	entry.Optional["number"] = "2"
+	entry.Optional["pages"] = "10--20"
+	entry.Optional["issn"] = "1234-5678"
+
+	out, err := ExportList([]*Entry{entry}, "mods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name
+		Records []struct{} `xml:"http://www.loc.gov/mods/v3 mods"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.XMLName.Space != modsNamespace || doc.XMLName.Local != "modsCollection" {
+		t.Errorf("root = %v, want {%s modsCollection}", doc.XMLName, modsNamespace)
+	}
+	if len(doc.Records) != 1 {
+		t.Errorf("len(Records) = %d, want 1", len(doc.Records))
+	}
+
+	for _, want := range []string{
+		`<mods version="3.7">`,
+		`<namePart type="given">First</namePart>`,
+		`<namePart type="family">von Last</namePart>`,
+		`<namePart type="termsOfAddress">Jr.</namePart>`,
+		`<name type="corporate">`,
+		`<namePart>World Health Organization</namePart>`,
+		`<roleTerm authority="marcrelator" type="text">author</roleTerm>`,
+		`<dateIssued encoding="w3cdtf">2006-01-02</dateIssued>`,
+		`<relatedItem type="host">`,
+		`<title>The Journal</title>`,
+		`<genre authority="marcgt">periodical</genre>`,
+		`<identifier type="issn">1234-5678</identifier>`,
+		`<detail type="issue">`,
+		`<start>10</start>`,
+		`<end>20</end>`,
+		`<identifier type="doi">123/456789</identifier>`,
+		`<recordIdentifier>last2006</recordIdentifier>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ExportList() does not contain %s", want)
+		}
+	}
+	if strings.Contains(out, "others") {
+		t.Errorf("ExportList() contains others")
+	}
+}
+
+func TestExMODS_Export(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := NewEntry("book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["title"] = "The Book"
+	entry.Required["author"] = "Knuth, Donald E."
+	entry.Required["date"] = "1984/1986"
+	entry.Optional["isbn"] = "0-201-13447-0"
+	entry.Optional["publisher"] = "Addison-Wesley"
+	entry.Optional["url"] = "https://example.com"
+	entry.Optional["urldate"] = "2020-01-02"
+	entry.Optional["language"] = "English"
+
+	out, err := entry.Export("mods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<modsCollection xmlns="http://www.loc.gov/mods/v3"`,
+		`<mods version="3.7">`,
+		`<genre authority="marcgt">book</genre>`,
+		`<publisher>Addison-Wesley</publisher>`,
+		`<dateIssued encoding="w3cdtf" point="start">1984</dateIssued>`,
+		`<dateIssued encoding="w3cdtf" point="end">1986</dateIssued>`,
+		`<identifier type="isbn">0-201-13447-0</identifier>`,
+		`<url dateLastAccessed="2020-01-02">https://example.com</url>`,
+		`<languageTerm type="text">English</languageTerm>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Export() does not contain %s", want)
+		}
+	}
+	if strings.Contains(out, "relatedItem") {
+		t.Errorf("Export() contains a related item")
+	}
+}
@@ -0,0 +1,296 @@
+package scholar
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Name is a person or organization name split in four parts according to the
+// BibLaTeX name format. For example, "de la Fontaine, Jr., Jean" is:
+//
+//	First: "Jean"
+//	Von:   "de la"
+//	Last:  "Fontaine"
+//	Jr:    "Jr."
+//
+// Braces are kept as they are written, so corporate names, such as
+// "{World Health Organization}", are stored as a single Last part.
+type Name struct {
+	First string
+	Von   string
+	Last  string
+	Jr    string
+}
+
+// ParseName parses a single name written in any of the BibLaTeX name formats:
+//
+//	First von Last
+//	von Last, First
+//	von Last, Jr, First
+func ParseName(s string) Name {
+	var n Name
+
+	parts := splitDepth(s, func(r rune) bool { return r == ',' }, false)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	switch len(parts) {
+	case 0:
+		return n
+	case 1:
+		words := nameWords(parts[0])
+		if len(words) == 0 {
+			return n
+		}
+		last := len(words) - 1
+		von := -1
+		for i := 0; i < last; i++ {
+			if isLowerWord(words[i]) {
+				von = i
+				break
+			}
+		}
+		if von == -1 {
+			n.First = strings.Join(words[:last], " ")
+			n.Last = words[last]
+			return n
+		}
+		end := von
+		for i := von; i < last; i++ {
+			if isLowerWord(words[i]) {
+				end = i
+			}
+		}
+		n.First = strings.Join(words[:von], " ")
+		n.Von = strings.Join(words[von:end+1], " ")
+		n.Last = strings.Join(words[end+1:], " ")
+	default:
+		words := nameWords(parts[0])
+		end := -1
+		for i := 0; i < len(words)-1; i++ {
+			if isLowerWord(words[i]) {
+				end = i
+			}
+		}
+		n.Von = strings.Join(words[:end+1], " ")
+		n.Last = strings.Join(words[end+1:], " ")
+		if len(parts) == 2 {
+			n.First = parts[1]
+		} else {
+			n.Jr = parts[1]
+			n.First = strings.Join(parts[2:], ", ")
+		}
+	}
+
+	return n
+}
+
+// ParseNames parses a list of names separated by "and", such as
+// "Einstein, Albert and von Neumann, John". A trailing "and others" is
+// returned as a name whose Others method returns true.
+func ParseNames(list string) []Name {
+	names := []Name{}
+	var current []string
+
+	flush := func() {
+		if len(current) > 0 {
+			names = append(names, ParseName(strings.Join(current, " ")))
+		}
+		current = nil
+	}
+
+	for _, word := range nameWords(list) {
+		if strings.EqualFold(word, "and") {
+			flush()
+			continue
+		}
+		current = append(current, word)
+	}
+	flush()
+
+	return names
+}
+
+// FormatNames returns the names as a BibLaTeX name list.
+func FormatNames(names []Name) string {
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = n.String()
+	}
+	return strings.Join(s, " and ")
+}
+
+// String implements the Stringer interface. It returns the name in the
+// "von Last, Jr, First" BibLaTeX format.
+func (n Name) String() string {
+	b := new(strings.Builder)
+
+	if n.Von != "" {
+		b.WriteString(n.Von)
+		b.WriteString(" ")
+	}
+	b.WriteString(n.Last)
+	if n.Jr != "" {
+		b.WriteString(", ")
+		b.WriteString(n.Jr)
+		b.WriteString(", ")
+		b.WriteString(n.First)
+	} else if n.First != "" {
+		b.WriteString(", ")
+		b.WriteString(n.First)
+	}
+
+	return b.String()
+}
+
+// Full returns the name in reading order ("First von Last Jr") without
+// braces. For example: "Jean de la Fontaine Jr."
+func (n Name) Full() string {
+	var s []string
+	for _, p := range []string{n.First, n.Von, n.Last, n.Jr} {
+		if p != "" {
+			s = append(s, unbrace(p))
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+// LastName returns the last name without braces.
+func (n Name) LastName() string {
+	return unbrace(n.Last)
+}
+
+// SortKey returns a lower case version of the name to be used for sorting.
+// Names are sorted by last name, first name, von part, and suffix.
+func (n Name) SortKey() string {
+	var s []string
+	for _, p := range []string{n.Last, n.First, n.Von, n.Jr} {
+		if p != "" {
+			s = append(s, strings.ToLower(unbrace(p)))
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+// Others checks if the name is the "others" placeholder used to truncate a
+// list of names.
+func (n Name) Others() bool {
+	return n.First == "" && n.Von == "" && n.Jr == "" && n.Last == "others"
+}
+
+// Names returns the list of names of the given field of the entry. It looks
+// for the field in the required fields first, then in the optional fields.
+func (e *Entry) Names(field string) []Name {
+	if value := e.Required[field]; value != "" {
+		return ParseNames(value)
+	}
+	return ParseNames(e.Optional[field])
+}
+
+// authorsOrEditors returns the authors of the entry, or the editors if the
+// entry has no author.
+func (e *Entry) authorsOrEditors() []Name {
+	if names := e.Names("author"); len(names) > 0 {
+		return names
+	}
+	return e.Names("editor")
+}
+
+// splitDepth splits s at every rune that matches sep outside braces. If
+// dropEmpty is true, empty substrings are not returned.
+func splitDepth(s string, sep func(rune) bool, dropEmpty bool) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	add := func(p string) {
+		if !dropEmpty || p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	for i, r := range s {
+		switch {
+		case r == '{':
+			depth++
+		case r == '}':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && sep(r):
+			add(s[start:i])
+			start = i + len(string(r))
+		}
+	}
+	if strings.TrimSpace(s) != "" {
+		add(s[start:])
+	}
+
+	return parts
+}
+
+// nameWords splits s into words at whitespace and ties (~) outside braces.
+func nameWords(s string) []string {
+	return splitDepth(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '~'
+	}, true)
+}
+
+// isLowerWord checks if a word of a name is part of the von part, which is
+// decided by the case of the first letter outside braces. Special characters,
+// such as {\'e} or {\OE}, are checked by the letter they represent. Words
+// without letters outside braces are treated as upper case.
+func isLowerWord(word string) bool {
+	depth := 0
+	for i, r := range word {
+		switch {
+		case r == '{':
+			if depth == 0 && strings.HasPrefix(word[i:], "{\\") {
+				return isLowerSpecial(word[i+2:])
+			}
+			depth++
+		case r == '}':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && unicode.IsLetter(r):
+			return unicode.IsLower(r)
+		}
+	}
+	return false
+}
+
+// isLowerSpecial checks the case of a special character given the text after
+// its backslash. Accent commands, such as \' or \v, take the case of the
+// accented letter. Other commands, such as \OE or \ss, take the case of the
+// command itself.
+func isLowerSpecial(s string) bool {
+	if s == "" {
+		return false
+	}
+	cmd := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	if cmd == -1 {
+		cmd = len(s)
+	}
+	if cmd == 0 || (cmd == 1 && strings.ContainsRune("bcdHkrtuv", rune(s[0]))) {
+		for _, r := range s[1:] {
+			if unicode.IsLetter(r) {
+				return unicode.IsLower(r)
+			}
+		}
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLower(r)
+}
+
+// unbrace removes all braces from s.
+func unbrace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '{' || r == '}' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,127 @@
+package scholar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Name
+	}{
+		{"Albert Einstein", Name{First: "Albert", Last: "Einstein"}},
+		{"Einstein, Albert", Name{First: "Albert", Last: "Einstein"}},
+		{"Einstein", Name{Last: "Einstein"}},
+		{"John von Neumann", Name{First: "John", Von: "von", Last: "Neumann"}},
+		{"von Neumann, John", Name{First: "John", Von: "von", Last: "Neumann"}},
+		{"Jean de la Fontaine", Name{First: "Jean", Von: "de la", Last: "Fontaine"}},
+		{"de la Fontaine, Jr., Jean", Name{First: "Jean", Von: "de la", Last: "Fontaine", Jr: "Jr."}},
+		{"King, Jr., Martin Luther", Name{First: "Martin Luther", Last: "King", Jr: "Jr."}},
+		{"Ludwig van Beethoven", Name{First: "Ludwig", Von: "van", Last: "Beethoven"}},
+		{"Charles Louis Xavier Joseph de la Vall{\\'e}e Poussin",
+			Name{First: "Charles Louis Xavier Joseph", Von: "de la", Last: "Vall{\\'e}e Poussin"}},
+		{"{World Health Organization}", Name{Last: "{World Health Organization}"}},
+		{"{Barnes and Noble, Inc.}", Name{Last: "{Barnes and Noble, Inc.}"}},
+		{"{\\'E}mile Zola", Name{First: "{\\'E}mile", Last: "Zola"}},
+		{"Donald~E. Knuth", Name{First: "Donald E.", Last: "Knuth"}},
+		{"", Name{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := ParseName(tt.in)
+			if got != tt.want {
+				t.Errorf("ParseName(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Last, First and Other, Name", []string{"Last, First", "Other, Name"}},
+		{"Albert Einstein AND Boris Podolsky and Nathan Rosen",
+			[]string{"Einstein, Albert", "Podolsky, Boris", "Rosen, Nathan"}},
+		{"{Barnes and Noble, Inc.} and von Neumann, John",
+			[]string{"{Barnes and Noble, Inc.}", "von Neumann, John"}},
+		{"Knuth, Donald and others", []string{"Knuth, Donald", "others"}},
+		{"Anderson, Sandy", []string{"Anderson, Sandy"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			names := ParseNames(tt.in)
+			got := make([]string, len(names))
+			for i, n := range names {
+				got[i] = n.String()
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ParseNames(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("others", func(t *testing.T) {
+		names := ParseNames("Knuth, Donald and others")
+		if !names[1].Others() {
+			t.Errorf("%#v.Others() = false, want true", names[1])
+		}
+		if names[0].Others() {
+			t.Errorf("%#v.Others() = true, want false", names[0])
+		}
+	})
+}
+
+func TestName_Full(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"de la Fontaine, Jr., Jean", "Jean de la Fontaine Jr."},
+		{"{World Health Organization}", "World Health Organization"},
+		{"Einstein, Albert", "Albert Einstein"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := ParseName(tt.in).Full(); got != tt.want {
+				t.Errorf("ParseName(%q).Full() = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntry_FirstAuthorLast(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		author string
+		want   string
+		key    string
+	}{
+		{"Last, First and Other, Name", "Last", "last2006"},
+		{"John von Neumann", "Neumann", "neumann2006"},
+		{"{World Health Organization}", "World Health Organization", "worldhealthorganization2006"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.author, func(t *testing.T) {
+			entry.Required["author"] = tt.author
+			entry.Key = ""
+			if got := entry.FirstAuthorLast(); got != tt.want {
+				t.Errorf("FirstAuthorLast() = %q, want %q", got, tt.want)
+			}
+			if got := entry.GetKey(); got != tt.key {
+				t.Errorf("GetKey() = %q, want %q", got, tt.key)
+			}
+		})
+	}
+}
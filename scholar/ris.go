@@ -1,7 +1,11 @@
 package scholar
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -16,74 +20,134 @@ func (ex *exRIS) parse(v string) string {
 	return ""
 }
 
-func (ex *exRIS) export(e *Entry) string {
-	ris := new(strings.Builder)
+// Begin implements the Exporter interface.
+func (ex *exRIS) Begin(w io.Writer) error {
+	return nil
+}
+
+// Entry implements the Exporter interface. Records are separated by a new
+// line.
+func (ex *exRIS) Entry(w io.Writer, e *Entry, i int) error {
+	ris := bufio.NewWriter(w)
 
 	// TY  - type
+	// ID  - key
 	fmt.Fprintf(ris, "TY  - %s", ex.parse(e.Type))
+	fmt.Fprintf(ris, "\nID  - %s", e.GetKey())
 
 	// field  - value
-	for field := range e.Required {
-		if value := e.Required[field]; value != "" {
-			switch field {
-			case "author":
-				authors := strings.Split(value, " and ")
-				for _, author := range authors {
-					fmt.Fprintf(ris, "\n%s  - %s", "AU", author)
-				}
-			case "date":
-				date := strings.Split(value, "-")
-				fmt.Fprintf(ris, "\n%s  - %s", "Y1", date[0])
-				if len(date) > 1 {
-					fmt.Fprintf(ris, "/%s", date[1])
-				}
-				fmt.Fprint(ris, "//")
-			case "urldate":
-				date := strings.Split(value, "-")
-				fmt.Fprintf(ris, "\n%s  - %s", "Y2", date[0])
-				if len(date) > 1 {
-					fmt.Fprintf(ris, "/%s", date[1])
-				}
-				fmt.Fprint(ris, "//")
-			default:
-				if f := ex.parse(field); f != "" {
-					fmt.Fprintf(ris, "\n%s  - %s", f, value)
+	write := func(field, value string) {
+		tag := ex.parse(field)
+		if tag == "" {
+			return
+		}
+		if field == "keywords" {
+			for _, kw := range strings.Split(value, ",") {
+				if kw = strings.TrimSpace(kw); kw != "" {
+					fmt.Fprintf(ris, "\n%s  - %s", tag, kw)
 				}
 			}
+			return
+		}
+		switch e.Datatype(field) {
+		case DataNameList:
+			for _, n := range ParseNames(value) {
+				fmt.Fprintf(ris, "\n%s  - %s", tag, risName(n))
+			}
+		case DataDate:
+			fmt.Fprintf(ris, "\n%s  - %s", tag, risDate(value))
+		case DataRange:
+			ranges, err := ParseRanges(value)
+			if err != nil {
+				fmt.Fprintf(ris, "\n%s  - %s", tag, value)
+				return
+			}
+			if len(ranges) > 1 {
+				// EP cannot hold the end of each range
+				fmt.Fprintf(ris, "\n%s  - %s", tag, value)
+				return
+			}
+			fmt.Fprintf(ris, "\n%s  - %s", tag, ranges[0].Start)
+			if r := ranges[0]; r.End != "" {
+				fmt.Fprintf(ris, "\n%s  - %s", "EP", r.End)
+			}
+		default:
+			fmt.Fprintf(ris, "\n%s  - %s", tag, value)
 		}
 	}
 
-	// field  - value
+	fields := make([]string, 0, len(e.Required))
+	for field := range e.Required {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := e.Required[field]; value != "" {
+			write(field, value)
+		}
+	}
+
+	fields = fields[:0]
 	for field := range e.Optional {
+		if _, ok := e.Required[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
 		if value := e.Optional[field]; value != "" {
-			switch field {
-			case "editor":
-				authors := strings.Split(value, " and ")
-				for _, author := range authors {
-					fmt.Fprintf(ris, "\n%s  - %s", "ED", author)
-				}
-			case "urldate":
-				date := strings.Split(value, "-")
-				fmt.Fprintf(ris, "\n%s  - %s", "Y2", date[0])
-				if len(date) > 1 {
-					fmt.Fprintf(ris, "/%s", date[1])
-				}
-				fmt.Fprint(ris, "//")
-			case "pages":
-				pages := strings.Split(value, "-")
-				fmt.Fprintf(ris, "\n%s  - %s", "SP", pages[0])
-				fmt.Fprintf(ris, "\n%s  - %s", "EP", pages[len(pages)-1])
-			default:
-				if f := ex.parse(field); f != "" {
-					fmt.Fprintf(ris, "\n%s  - %s", f, value)
-				}
-			}
+			write(field, value)
 		}
 	}
 
-	ris.WriteString("\nER  - ")
+	// L1  - attached file
+	for _, a := range e.Files {
+		fmt.Fprintf(ris, "\n%s  - %s", "L1", a.Path)
+	}
+
+	ris.WriteString("\nER  - \n")
+
+	return ris.Flush()
+}
+
+// End implements the Exporter interface.
+func (ex *exRIS) End(w io.Writer) error {
+	return nil
+}
+
+// risDate returns the date in the "YYYY/MM/DD/" RIS format.
+func risDate(value string) string {
+	d, err := ParseDate(value)
+	if err != nil {
+		return value
+	}
+	date := fmt.Sprintf("%04d/", d.Year)
+	if d.Month != 0 {
+		date += fmt.Sprintf("%02d", d.Month)
+	}
+	date += "/"
+	if d.Day != 0 {
+		date += fmt.Sprintf("%02d", d.Day)
+	}
+	return date + "/"
+}
 
-	return ris.String()
+// risName returns the name in the "Last, First, Suffix" RIS format. A
+// braced name, such as "{World Health Organization}", is kept braced so it is
+// imported back as a single name.
+func risName(n Name) string {
+	if n.First == "" && n.Von == "" && n.Jr == "" &&
+		strings.HasPrefix(n.Last, "{") && strings.HasSuffix(n.Last, "}") {
+		return n.Last
+	}
+	name := unbrace(strings.TrimSpace(n.Von + " " + n.Last))
+	if n.First != "" || n.Jr != "" {
+		name += ", " + unbrace(n.First)
+	}
+	if n.Jr != "" {
+		name += ", " + unbrace(n.Jr)
+	}
+	return name
 }
 
 var ris = &exRIS{
@@ -96,6 +160,11 @@ var ris = &exRIS{
 		"inbook":        "CHAP",
 		"patent":        "PAT",
 		"report":        "RPRT",
+		"author":        "AU",
+		"editor":        "ED",
+		"date":          "Y1",
+		"urldate":       "Y2",
+		"pages":         "SP",
 		"title":         "TI",
 		"journaltitle":  "JO",
 		"booktitle":     "T2",
@@ -103,9 +172,261 @@ var ris = &exRIS{
 		"number":        "M1",
 		"abstract":      "N2",
 		"publisher":     "PB",
-		"ISBN":          "SN",
-		"ISSN":          "SN",
+		"isbn":          "SN",
+		"issn":          "SN",
 		"url":           "UR",
 		"volume":        "VL",
+		"keywords":      "KW",
+		"location":      "CY",
+		"edition":       "ET",
+		"series":        "T3",
+		"note":          "N1",
+		"language":      "LA",
 	},
 }
+
+type imRIS struct {
+	dict map[string]string
+}
+
+// newRISParser returns a RIS importer that reverses the mapping of ex, so
+// that exported files are imported back without changes. Tags in extra are
+// added to the mapping, but do not override it.
+func newRISParser(ex *exRIS, extra map[string]string) *imRIS {
+	im := &imRIS{dict: make(map[string]string)}
+	for name, tag := range ex.dict {
+		im.dict[tag] = name
+	}
+	for tag, name := range extra {
+		if _, ok := im.dict[tag]; !ok {
+			im.dict[tag] = name
+		}
+	}
+	return im
+}
+
+var risLineRx = regexp.MustCompile(`^([A-Z][A-Z0-9])  -(?: (.*))?$`)
+
+type risRecord struct {
+	line int
+	tags []bibField
+}
+
+// read parses the records of a RIS file. Records of a type that is not loaded
+// are read as "misc" entries. Tags without a field are kept as optional
+// fields named by the tag in lower case.
+func (im *imRIS) read(r io.Reader) ([]*Entry, error) {
+	records, err := im.records(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(records))
+	for _, rec := range records {
+		e, err := im.entry(rec)
+		if err != nil {
+			return nil, getError("Import", errNotDefined, err).
+				info(fmt.Sprintf("line %d", rec.line))
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func (im *imRIS) records(r io.Reader) ([]risRecord, error) {
+	var records []risRecord
+	var rec *risRecord
+
+	errorf := func(line int, format string, a ...interface{}) error {
+		return getError("Import", ErrParse, nil).
+			info(fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, a...)))
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		m := risLineRx.FindStringSubmatch(text)
+		if m == nil {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			if rec == nil || len(rec.tags) == 0 {
+				return nil, errorf(line, "expected a tag, found %q", text)
+			}
+			// continuation of the previous value
+			last := &rec.tags[len(rec.tags)-1]
+			last.value = strings.TrimSpace(last.value + " " + strings.TrimSpace(text))
+			continue
+		}
+
+		tag, value := m[1], strings.TrimSpace(m[2])
+		switch {
+		case tag == "TY":
+			if rec != nil {
+				return nil, errorf(line, "record of line %d is not closed with ER", rec.line)
+			}
+			rec = &risRecord{line: line}
+		case rec == nil:
+			return nil, errorf(line, "expected TY, found %s", tag)
+		case tag == "ER":
+			records = append(records, *rec)
+			rec = nil
+			continue
+		}
+		rec.tags = append(rec.tags, bibField{name: tag, value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, getError("Import", ErrIO, err)
+	}
+	if rec != nil {
+		return nil, errorf(line, "record of line %d is not closed with ER", rec.line)
+	}
+
+	return records, nil
+}
+
+func (im *imRIS) entry(rec risRecord) (*Entry, error) {
+	e, err := NewEntry(im.dict[rec.tags[0].value])
+	if IsError(ErrTypeNotFound, err) {
+		e, err = NewEntry("misc")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]string)
+	var fields []string
+	add := func(field, value string) {
+		if _, ok := values[field]; !ok {
+			fields = append(fields, field)
+		}
+		values[field] = append(values[field], value)
+	}
+
+	var start, end string
+	for _, t := range rec.tags[1:] {
+		if t.value == "" {
+			continue
+		}
+		switch t.name {
+		case "SP":
+			start = t.value
+			continue
+		case "EP":
+			end = t.value
+			continue
+		case "ID":
+			e.Key = t.value
+			continue
+		case "L1":
+			e.Attach(Attachment{Path: t.value})
+			continue
+		case "SN":
+			if checkISSN(t.value) == nil {
+				add("issn", t.value)
+			} else {
+				add("isbn", t.value)
+			}
+			continue
+		}
+
+		field, ok := im.dict[t.name]
+		if !ok {
+			field = strings.ToLower(t.name)
+		}
+		switch e.Datatype(field) {
+		case DataNameList:
+			add(field, ParseName(risBibName(t.value)).String())
+		case DataDate:
+			add(field, risParseDate(t.value))
+		default:
+			add(field, t.value)
+		}
+	}
+
+	// Repeated tags are joined for lists and notes, otherwise the first value
+	// is used, such as JF over JA.
+	for _, field := range fields {
+		value := values[field][0]
+		switch {
+		case e.Datatype(field) == DataNameList:
+			value = strings.Join(values[field], " and ")
+		case field == "keywords":
+			value = strings.Join(values[field], ", ")
+		case field == "note":
+			value = strings.Join(values[field], "; ")
+		}
+		e.Set(field, value)
+	}
+
+	if start != "" {
+		pages := start
+		if end != "" && end != start {
+			pages += "--" + end
+		}
+		e.Set("pages", pages)
+	}
+
+	return e, nil
+}
+
+// risBibName returns a name in the "Last, First, Suffix" RIS format as a
+// "Last, Suffix, First" BibTeX name.
+func risBibName(name string) string {
+	parts := strings.Split(name, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) == 3 {
+		return parts[0] + ", " + parts[2] + ", " + parts[1]
+	}
+	return name
+}
+
+// risParseDate returns a date in the "YYYY/MM/DD/other" RIS format in
+// YYYY[-MM[-DD]] format.
+func risParseDate(value string) string {
+	parts := strings.Split(value, "/")
+	date := parts[0]
+	if len(parts) > 1 && parts[1] != "" {
+		date += "-" + parts[1]
+		if len(parts) > 2 && parts[2] != "" {
+			date += "-" + parts[2]
+		}
+	}
+	if checkDate(date) != nil {
+		return value
+	}
+	return date
+}
+
+var risParser = newRISParser(ris, map[string]string{
+	"GEN":   "misc",
+	"CONF":  "inproceedings",
+	"EJOUR": "article",
+	"MGZN":  "article",
+	"NEWS":  "article",
+	"WEB":   "online",
+	"EBOOK": "book",
+	"ECHAP": "inbook",
+	"UNPB":  "unpublished",
+	"DATA":  "dataset",
+	"COMP":  "software",
+	"A1":    "author",
+	"A2":    "editor",
+	"T1":    "title",
+	"JF":    "journaltitle",
+	"JA":    "journaltitle",
+	"PY":    "date",
+	"DA":    "date",
+	"IS":    "number",
+	"AB":    "abstract",
+})
@@ -0,0 +1,149 @@
+package scholar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImport_RIS(t *testing.T) {
+	if err := loadTypes(mockEntryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	in := "\ufeffTY  - JOUR\r\n" +
+		"AU  - Einstein, Albert\r\n" +
+		"AU  - Neumann, John, Jr.\r\n" +
+		"TI  - Zur Elektrodynamik\r\n" +
+		"  bewegter Körper\r\n" +
+		"JF  - Annalen der Physik\r\n" +
+		"JA  - Ann. Phys.\r\n" +
+		"PY  - 1905\r\n" +
+		"SP  - 891\r\n" +
+		"EP  - 921\r\n" +
+		"KW  - relativity\r\n" +
+		"KW  - physics\r\n" +
+		"SN  - 0003-3804\r\n" +
+		"DB  - Example\r\n" +
+		"ER  - \r\n" +
+		"\r\n" +
+		"TY  - BOOK\r\n" +
+		"AU  - Knuth, Donald E.\r\n" +
+		"TI  - The TeXbook\r\n" +
+		"Y1  - 1984/03/01/\r\n" +
+		"SN  - 0-201-13447-0\r\n" +
+		"ER  - \r\n"
+
+	entries, err := Import(strings.NewReader(in), "ris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Import() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Type != "article" || entries[1].Type != "book" {
+		t.Errorf("types = %q, %q, want article, book", entries[0].Type, entries[1].Type)
+	}
+
+	tests := []struct {
+		entry int
+		field string
+		want  string
+	}{
+		{0, "author", "Einstein, Albert and Neumann, Jr., John"},
+		{0, "title", "Zur Elektrodynamik bewegter Körper"},
+		{0, "journaltitle", "Annalen der Physik"},
+		{0, "date", "1905"},
+		{0, "pages", "891--921"},
+		{0, "keywords", "relativity, physics"},
+		{0, "issn", "0003-3804"},
+		{0, "db", "Example"},
+		{1, "date", "1984-03-01"},
+		{1, "isbn", "0-201-13447-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := entries[tt.entry].field(tt.field); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImport_RISRoundTrip(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["date"] = "2006-03"
+	entry.Optional["doi"] = "10.1000/182"
+	entry.Optional["pages"] = "10--20"
+	entry.Optional["location"] = "Geneva"
+	entry.Optional["edition"] = "2"
+	entry.Optional["series"] = "Technical Reports"
+	entry.Optional["note"] = "A note"
+	entry.Optional["language"] = "English"
+	entry.Optional["keywords"] = "health, policy"
+	entry.Required["author"] = "{World Health Organization} and Last, First"
+	entry.Attach(Attachment{Path: "paper.pdf"})
+
+	out, err := ExportList([]*Entry{entry}, "ris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Import(strings.NewReader(out), "ris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := entries[0]
+	if got.Type != entry.Type || got.Key != entry.GetKey() {
+		t.Errorf("entry = %s{%s}, want %s{%s}", got.Type, got.Key, entry.Type, entry.GetKey())
+	}
+	for _, field := range []string{"author", "title", "journaltitle", "date", "doi", "pages",
+		"location", "edition", "series", "note", "language", "keywords"} {
+		if got.field(field) != entry.field(field) {
+			t.Errorf("%s = %q, want %q", field, got.field(field), entry.field(field))
+		}
+	}
+	if len(got.Files) != 1 || got.Files[0].Path != "paper.pdf" {
+		t.Errorf("Files = %v, want [paper.pdf]", got.Files)
+	}
+
+	t.Run("page ranges", func(t *testing.T) {
+		entry.Optional["pages"] = "1--5, 9--12, 20--25"
+		out, err := ExportList([]*Entry{entry}, "ris")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "SP  - 1--5, 9--12, 20--25\n") || strings.Contains(out, "EP  -") {
+			t.Errorf("ExportList() = %s, want SP  - 1--5, 9--12, 20--25 and no EP", out)
+		}
+		entries, err := Import(strings.NewReader(out), "ris")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := entries[0].field("pages"); got != entry.Optional["pages"] {
+			t.Errorf("pages = %q, want %q", got, entry.Optional["pages"])
+		}
+	})
+}
+
+func TestImport_RISError(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		line string
+	}{
+		{"no TY", "AU  - Last, First\nER  - \n", "line 1:"},
+		{"not closed", "TY  - JOUR\nTI  - Title\n\nTY  - BOOK\nER  - \n", "line 4:"},
+		{"no ER", "TY  - JOUR\nTI  - Title\n", "line 2:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(strings.NewReader(tt.in), "ris")
+			if !IsError(ErrParse, err) || !strings.Contains(err.Error(), tt.line) {
+				t.Errorf("Import() error = %v, want ErrParse at %q", err, tt.line)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"sort"
+	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -18,21 +19,91 @@ var EntryTypes map[string]*EntryType
 func LoadTypes(file string) error {
 	d, err := ioutil.ReadFile(file)
 	if err != nil {
-		return err
+		return getError("LoadTypes", ErrIO, err)
+	}
+	if err := loadTypes(d); err != nil {
+		return getError("LoadTypes", errNotDefined, err).info(file)
 	}
-	return loadTypes(d)
+	return nil
 }
 
 func loadTypes(b []byte) error {
-	err := yaml.Unmarshal(b, &EntryTypes)
+	var types map[string]*EntryType
+	err := yaml.Unmarshal(b, &types)
 	if err != nil {
-		return err
+		return getError("LoadTypes", ErrParse, err)
 	}
 
-	for name, entry := range EntryTypes {
+	for name, entry := range types {
+		if entry == nil {
+			entry = &EntryType{}
+			types[name] = entry
+		}
 		entry.Type = name
 	}
 
+	if err := resolveTypes(types); err != nil {
+		return err
+	}
+
+	for name, entry := range types {
+		for _, fields := range []map[string]*Field{entry.Required, entry.Optional} {
+			for field, f := range fields {
+				if f == nil {
+					f = &Field{}
+					fields[field] = f
+				}
+				if err := f.compile(field); err != nil {
+					return getError("LoadTypes", errNotDefined, err).
+						info(fmt.Sprintf("in entry type %q", name))
+				}
+			}
+		}
+	}
+
+	EntryTypes = types
+
+	return nil
+}
+
+// resolveTypes copies the description, fields, and aliases of each entry type
+// to the entry types that extend it.
+func resolveTypes(types map[string]*EntryType) error {
+	resolved := make(map[string]bool)
+	var resolve func(name string, path []string) error
+
+	resolve = func(name string, path []string) error {
+		if resolved[name] {
+			return nil
+		}
+		entry := types[name]
+		for _, p := range path {
+			if p == name {
+				return getError("LoadTypes", ErrTypeNotFound, nil).
+					info(fmt.Sprintf("%q extends itself: %s", name, strings.Join(append(path, name), " -> ")))
+			}
+		}
+		if entry.Extends != "" {
+			parent, ok := types[entry.Extends]
+			if !ok {
+				return getError("LoadTypes", ErrTypeNotFound, nil).
+					info(fmt.Sprintf("%q extends %q, which is not a valid entry type", name, entry.Extends))
+			}
+			if err := resolve(entry.Extends, append(path, name)); err != nil {
+				return err
+			}
+			entry.inherit(parent)
+		}
+		resolved[name] = true
+		return nil
+	}
+
+	for name := range types {
+		if err := resolve(name, nil); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,382 @@
+package scholar
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Markup is the markup used to write formatted references.
+type Markup uint8
+
+const (
+	// Text writes references as plain text.
+	Text Markup = iota
+	// Markdown writes references as Markdown.
+	Markdown
+	// HTML writes references as HTML.
+	HTML
+)
+
+var markups = map[string]Markup{
+	"text":     Text,
+	"markdown": Markdown,
+	"html":     HTML,
+}
+
+// ParseMarkup returns the markup with the given name: "text", "markdown", or
+// "html". It returns an ErrFormatNotFound error if the name is unknown.
+func ParseMarkup(name string) (Markup, error) {
+	if m, ok := markups[name]; ok {
+		return m, nil
+	}
+	return Text, getError("ParseMarkup", ErrFormatNotFound, nil).
+		info(fmt.Sprintf("%q is not a markup, use text, markdown, or html", name))
+}
+
+// String implements the Stringer interface.
+func (m Markup) String() string {
+	for name, mm := range markups {
+		if mm == m {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+)
+
+func (m Markup) escape(s string) string {
+	switch m {
+	case Markdown:
+		return markdownReplacer.Replace(s)
+	case HTML:
+		return html.EscapeString(s)
+	}
+	return s
+}
+
+func (m Markup) italic(s string) string {
+	switch m {
+	case Markdown:
+		return "*" + s + "*"
+	case HTML:
+		return "<i>" + s + "</i>"
+	}
+	return s
+}
+
+func (m Markup) bold(s string) string {
+	switch m {
+	case Markdown:
+		return "**" + s + "**"
+	case HTML:
+		return "<b>" + s + "</b>"
+	}
+	return s
+}
+
+func (m Markup) superscript(s string) string {
+	switch m {
+	case Markdown, HTML:
+		return "<sup>" + s + "</sup>"
+	}
+	return s
+}
+
+func (m Markup) link(url string) string {
+	switch m {
+	case Markdown:
+		return "<" + url + ">"
+	case HTML:
+		u := html.EscapeString(url)
+		return `<a href="` + u + `">` + u + `</a>`
+	}
+	return url
+}
+
+// list returns the references of a bibliography as a single document.
+func (m Markup) list(refs []string) string {
+	b := new(strings.Builder)
+	for i, ref := range refs {
+		switch m {
+		case HTML:
+			b.WriteString(`<p class="reference">` + ref + "</p>\n")
+		default:
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(ref + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Style formats entries as in-text citations and as the references of a
+// bibliography.
+type Style interface {
+	// Citation returns the in-text citation of the entries, such as
+	// "(Last, 2006)" or "[1], [2]". Entries are numbered in the order of the
+	// bibliography.
+	Citation(entries []*Entry, m Markup) string
+	// Bibliography returns the reference of each entry, in the order
+	// defined by the style.
+	Bibliography(entries []*Entry, m Markup) []string
+}
+
+var (
+	stylesMu sync.RWMutex
+	styles   = map[string]Style{
+		"apa":     apa,
+		"ieee":    ieee,
+		"chicago": chicago,
+		"harvard": harvard,
+	}
+)
+
+// RegisterStyle makes a citation style available by name. If a style with
+// the same name is already registered, it is replaced. It panics if s is nil.
+func RegisterStyle(name string, s Style) {
+	if s == nil {
+		panic("scholar: RegisterStyle style is nil")
+	}
+	stylesMu.Lock()
+	defer stylesMu.Unlock()
+	styles[name] = s
+}
+
+// Styles returns the sorted names of the registered citation styles.
+func Styles() []string {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+
+	names := make([]string, 0, len(styles))
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func getStyle(style string) (Style, error) {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+
+	if s, ok := styles[style]; ok {
+		return s, nil
+	}
+	return nil, getError("Style", ErrStyleNotFound, nil).
+		info(fmt.Sprintf("%q is not a registered style", style))
+}
+
+// Bibliography returns the references of the entries formatted with the given
+// style. If the style is not registered, it returns an ErrStyleNotFound error.
+func Bibliography(entries []*Entry, style string, m Markup) (string, error) {
+	s, err := getStyle(style)
+	if err != nil {
+		return "", err
+	}
+	return m.list(s.Bibliography(entries, m)), nil
+}
+
+// Citation returns the in-text citation of the entries formatted with the
+// given style. If the style is not registered, it returns an ErrStyleNotFound
+// error.
+func Citation(entries []*Entry, style string, m Markup) (string, error) {
+	s, err := getStyle(style)
+	if err != nil {
+		return "", err
+	}
+	return s.Citation(entries, m), nil
+}
+
+// block is a part of a reference. It is either a value of the entry or a
+// group of blocks joined by a delimiter. Blocks without a value are omitted,
+// together with their prefix and suffix.
+type block struct {
+	value  func(e *Entry) string
+	blocks []block
+	delim  string
+
+	prefix string
+	suffix string
+	italic bool
+	quoted bool
+	link   bool
+}
+
+// group returns a block with the non-empty blocks separated by delim.
+func group(delim string, blocks ...block) block {
+	return block{blocks: blocks, delim: delim}
+}
+
+// piece is a rendered block.
+type piece struct {
+	text   string // with markup
+	plain  string // without markup, to check the punctuation
+	quoted bool   // ends with a closing quote
+}
+
+// textStyle is a style defined by the layout of the reference of each entry
+// type.
+type textStyle struct {
+	layouts map[string]block
+	// sorted sorts the references by creator, year, and title. Otherwise,
+	// the order of the entries is kept.
+	sorted bool
+	// label is the format of the number of each reference, such as "[%d]".
+	label string
+	// cite is the layout of each entry in an in-text citation, which is
+	// written as citePrefix, the entries separated by citeDelim, and
+	// citeSuffix. If the style has a label, the label is used instead.
+	cite                              block
+	citePrefix, citeDelim, citeSuffix string
+	// quotes are the opening and closing quotes of quoted blocks. If
+	// punctInQuotes is set, commas and periods are moved inside the quotes.
+	quotes        [2]string
+	punctInQuotes bool
+}
+
+// order returns the entries in the order of the bibliography.
+func (s *textStyle) order(entries []*Entry) []*Entry {
+	if !s.sorted {
+		return entries
+	}
+	entries = append([]*Entry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return referenceSortKey(entries[i]) < referenceSortKey(entries[j])
+	})
+	return entries
+}
+
+// Citation implements the Style interface.
+func (s *textStyle) Citation(entries []*Entry, m Markup) string {
+	cites := make([]string, len(entries))
+	for i, e := range s.order(entries) {
+		if s.label != "" {
+			cites[i] = m.escape(fmt.Sprintf(s.label, i+1))
+			continue
+		}
+		cites[i] = s.render(s.cite, e, m).text
+	}
+	return m.escape(s.citePrefix) + strings.Join(cites, m.escape(s.citeDelim)) + m.escape(s.citeSuffix)
+}
+
+// Bibliography implements the Style interface.
+func (s *textStyle) Bibliography(entries []*Entry, m Markup) []string {
+	entries = s.order(entries)
+	refs := make([]string, len(entries))
+	for i, e := range entries {
+		refs[i] = s.Reference(e, m)
+		if s.label != "" {
+			refs[i] = m.escape(fmt.Sprintf(s.label, i+1)) + " " + refs[i]
+		}
+	}
+	return refs
+}
+
+// Reference returns the reference of an entry.
+func (s *textStyle) Reference(e *Entry, m Markup) string {
+	layout, ok := s.layouts[e.Type]
+	if !ok {
+		layout = s.layouts["misc"]
+	}
+	return strings.TrimSpace(s.render(layout, e, m).text)
+}
+
+func (s *textStyle) render(b block, e *Entry, m Markup) piece {
+	var p piece
+	if b.value != nil {
+		v := strings.TrimSpace(b.value(e))
+		if v == "" {
+			return p
+		}
+		p = piece{text: m.escape(v), plain: v}
+		if b.link {
+			p.text = m.link(v)
+		}
+	} else {
+		for _, child := range b.blocks {
+			c := s.render(child, e, m)
+			if c.plain == "" {
+				continue
+			}
+			if p.plain != "" {
+				s.punctuate(&p, b.delim, m)
+			}
+			p.text += c.text
+			p.plain += c.plain
+			p.quoted = c.quoted
+		}
+		if p.plain == "" {
+			return p
+		}
+	}
+
+	if b.quoted {
+		p.text = m.escape(s.quotes[0]) + p.text + m.escape(s.quotes[1])
+		p.plain = s.quotes[0] + p.plain + s.quotes[1]
+		p.quoted = true
+	}
+	if b.italic {
+		p.text = m.italic(p.text)
+		p.quoted = false
+	}
+	if b.prefix != "" {
+		p.text = m.escape(b.prefix) + p.text
+		p.plain = b.prefix + p.plain
+	}
+	s.punctuate(&p, b.suffix, m)
+
+	return p
+}
+
+// punctuate appends punct to the piece. A period is not repeated after a
+// punctuation mark, and, if the style says so, a comma or a period after a
+// closing quote is moved inside the quotes.
+func (s *textStyle) punctuate(p *piece, punct string, m Markup) {
+	if punct == "" {
+		return
+	}
+	if r, _ := utf8.DecodeLastRuneInString(p.plain); strings.HasPrefix(punct, ".") && strings.ContainsRune(".?!", r) {
+		punct = punct[1:]
+	}
+	if p.quoted && s.punctInQuotes && (strings.HasPrefix(punct, ",") || strings.HasPrefix(punct, ".")) {
+		q := s.quotes[1]
+		plain := strings.TrimSuffix(p.plain, q)
+		if r, _ := utf8.DecodeLastRuneInString(plain); !strings.ContainsRune(".?!", r) {
+			p.text = strings.TrimSuffix(p.text, m.escape(q)) + punct[:1] + m.escape(q)
+			p.plain = plain + punct[:1] + q
+		}
+		punct = punct[1:]
+	}
+	if punct == "" {
+		return
+	}
+	p.text += m.escape(punct)
+	p.plain += punct
+	p.quoted = false
+}
+
+// referenceSortKey returns the key used to sort references by creator, year,
+// and title.
+func referenceSortKey(e *Entry) string {
+	var names []string
+	for _, n := range e.authorsOrEditors() {
+		names = append(names, n.SortKey())
+	}
+	return strings.Join(names, "\x01") + "\x00" + e.Year() + "\x00" + strings.ToLower(unbrace(e.field("title")))
+}
@@ -0,0 +1,143 @@
+package scholar
+
+import (
+	"testing"
+)
+
+func mockReferences(t *testing.T) []*Entry {
+	article, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	article.Required["author"] = "Last, First Middle and von Other, Jean-Paul and Third, Ann"
+	article.Required["title"] = "Why & How?"
+	article.Required["date"] = "2006-03-02"
+	article.Optional["volume"] = "3"
+	article.Optional["number"] = "2"
+	article.Optional["pages"] = "10--20"
+	article.Optional["doi"] = "10.1000/182"
+
+	book, err := NewEntry("book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book.Required["author"] = "Knuth, Donald E."
+	book.Required["title"] = "The TeXbook"
+	book.Required["date"] = "1984"
+	book.Optional["publisher"] = "Addison-Wesley"
+	book.Optional["location"] = "Reading, MA"
+	book.Optional["edition"] = "2"
+
+	return []*Entry{book, article}
+}
+
+func TestBibliography(t *testing.T) {
+	entries := mockReferences(t)
+
+	tests := []struct {
+		style  string
+		markup Markup
+		want   string
+	}{
+		{"apa", Text, `Knuth, D. E. (1984). The TeXbook (2nd ed.). Addison-Wesley.
+
+Last, F. M., von Other, J.-P., & Third, A. (2006). Why & How? The Journal, 3(2), 10–20. https://doi.org/10.1000/182
+`},
+		{"apa", Markdown, `Knuth, D. E. (1984). *The TeXbook* (2nd ed.). Addison-Wesley.
+
+Last, F. M., von Other, J.-P., & Third, A. (2006). Why & How? *The Journal*, *3*(2), 10–20. <https://doi.org/10.1000/182>
+`},
+		{"ieee", Text, `[1] D. E. Knuth, The TeXbook, 2nd ed., Reading, MA: Addison-Wesley, 1984.
+
+[2] F. M. Last, J.-P. von Other, and A. Third, “Why & How?” The Journal, vol. 3, no. 2, pp. 10–20, Mar. 2006. doi: 10.1000/182.
+`},
+		{"chicago", HTML, `<p class="reference">Knuth, Donald E. <i>The TeXbook</i>. 2nd ed. Reading, MA: Addison-Wesley, 1984.</p>
+<p class="reference">Last, First Middle, Jean-Paul von Other, and Ann Third. “Why &amp; How?” <i>The Journal</i> 3, no. 2 (2006): 10–20. <a href="https://doi.org/10.1000/182">https://doi.org/10.1000/182</a>.</p>
+`},
+		{"harvard", Text, `Knuth, D.E. (1984) The TeXbook. 2nd edn. Reading, MA: Addison-Wesley.
+
+Last, F.M., von Other, J.-P. and Third, A. (2006) ‘Why & How?’, The Journal, 3(2), pp. 10–20. Available at: https://doi.org/10.1000/182.
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style+"/"+tt.markup.String(), func(t *testing.T) {
+			got, err := Bibliography(entries, tt.style, tt.markup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Bibliography() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Bibliography(entries, "unknown", Text); !IsError(ErrStyleNotFound, err) {
+		t.Errorf("Bibliography() error = %v, want ErrStyleNotFound", err)
+	}
+}
+
+func TestNameStyle_Format(t *testing.T) {
+	names := func(n int) []Name {
+		var ns []Name
+		for i := 0; i < n; i++ {
+			ns = append(ns, Name{First: "First", Last: string(rune('A' + i))})
+		}
+		return ns
+	}
+
+	tests := []struct {
+		name  string
+		style nameStyle
+		names []Name
+		want  string
+	}{
+		{"apa two", apaNames, names(2), "A, F., & B, F."},
+		{"apa ellipsis", apaNames, names(22), "A, F., B, F., C, F., D, F., E, F., F, F., G, F., H, F., I, F., J, F., K, F., L, F., M, F., N, F., O, F., P, F., Q, F., R, F., S, F., . . . V, F."},
+		{"ieee et al", ieeeNames, names(7), "F. A et al."},
+		{"ieee others", ieeeNames, append(names(2), Name{Last: "others"}), "F. A, F. B et al."},
+		{"chicago", chicagoNames, names(3), "A, First, First B, and First C"},
+		{"harvard", harvardNames, names(3), "A, F., B, F. and C, F."},
+		{"corporate", apaNames, []Name{{Last: "{World Health Organization}"}}, "World Health Organization"},
+		{"suffix", ieeeNames, []Name{{First: "John", Last: "Neumann", Von: "von", Jr: "Jr."}}, "J. von Neumann, Jr."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.style.format(tt.names); got != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkup(t *testing.T) {
+	for _, name := range []string{"text", "markdown", "html"} {
+		m, err := ParseMarkup(name)
+		if err != nil || m.String() != name {
+			t.Errorf("ParseMarkup(%q) = %v, %v", name, m, err)
+		}
+	}
+	if _, err := ParseMarkup("pdf"); !IsError(ErrFormatNotFound, err) {
+		t.Errorf("ParseMarkup(pdf) error = %v, want ErrFormatNotFound", err)
+	}
+}
+
+func TestTextStyle_Citation(t *testing.T) {
+	entries := mockReferences(t)
+
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{"apa", "(Knuth, 1984; Last et al., 2006)"},
+		{"ieee", "[1], [2]"},
+	}
+	for _, tt := range tests {
+		got, err := Citation(entries, tt.style, Text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Citation(%s) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,665 @@
+package scholar
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type nameOrder uint8
+
+const (
+	// "First Last"
+	nameDirect nameOrder = iota
+	// "Last, First"
+	nameInverted
+	// "Last, First" for the first name and "First Last" for the others.
+	nameFirstInverted
+)
+
+// nameStyle defines how a list of names is written in a reference.
+type nameStyle struct {
+	order nameOrder
+	// short writes only the last names.
+	short bool
+	// initials writes the first names as initials, separated by initialSep.
+	initials   bool
+	initialSep string
+	// sep separates the names. and2 separates two names, and andN
+	// separates the last name of a longer list.
+	sep, and2, andN string
+	// If there are etAlMin names or more, only the first etAlUse names are
+	// written, followed by etAl. If etAlLast is set, the last name is written
+	// after etAl.
+	etAlMin, etAlUse int
+	etAl             string
+	etAlLast         bool
+}
+
+// initials returns the initials of a first name, such as "J.-P. R." for
+// "Jean-Paul Rodolphe".
+func initials(first, sep string) string {
+	var words []string
+	for _, word := range strings.Fields(unbrace(first)) {
+		var parts []string
+		for _, part := range strings.Split(word, "-") {
+			for _, r := range part {
+				parts = append(parts, string(r)+".")
+				break
+			}
+		}
+		words = append(words, strings.Join(parts, "-"))
+	}
+	return strings.Join(words, sep)
+}
+
+func (ns nameStyle) name(n Name, inverted bool) string {
+	if n.First == "" && n.Von == "" && n.Jr == "" {
+		return unbrace(n.Last)
+	}
+
+	last := unbrace(strings.TrimSpace(n.Von + " " + n.Last))
+	if ns.short {
+		return last
+	}
+
+	first := unbrace(n.First)
+	if ns.initials {
+		first = initials(n.First, ns.initialSep)
+	}
+
+	var s string
+	switch {
+	case first == "":
+		s = last
+	case inverted:
+		s = last + ", " + first
+	default:
+		s = first + " " + last
+	}
+	if n.Jr != "" {
+		s += ", " + unbrace(n.Jr)
+	}
+	return s
+}
+
+// format returns the list of names written with the style.
+func (ns nameStyle) format(names []Name) string {
+	etAl := false
+	for i, n := range names {
+		if n.Others() {
+			names, etAl = names[:i], true
+			break
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	last := names[len(names)-1]
+	if ns.etAlMin > 0 && len(names) >= ns.etAlMin {
+		names, etAl = names[:ns.etAlUse], true
+	} else if etAl {
+		last = Name{}
+	}
+
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = ns.name(n, ns.order == nameInverted || ns.order == nameFirstInverted && i == 0)
+	}
+
+	switch {
+	case etAl && ns.etAlLast && last != Name{}:
+		return strings.Join(s, ns.sep) + ns.etAl + ns.name(last, ns.order == nameInverted)
+	case etAl:
+		return strings.Join(s, ns.sep) + ns.etAl
+	case len(s) == 1:
+		return s[0]
+	case len(s) == 2:
+		return s[0] + ns.and2 + s[1]
+	}
+	return strings.Join(s[:len(s)-1], ns.sep) + ns.andN + s[len(s)-1]
+}
+
+// refEditors returns the editors followed by one or many, depending on the
+// number of editors.
+func refEditors(ns nameStyle, one, many string) func(*Entry) string {
+	return func(e *Entry) string {
+		eds := e.Names("editor")
+		s := ns.format(eds)
+		switch {
+		case s == "":
+			return ""
+		case len(eds) == 1:
+			return s + one
+		}
+		return s + many
+	}
+}
+
+// refCreators returns the authors, or the editors if the entry has no author.
+func refCreators(ns nameStyle, one, many string) func(*Entry) string {
+	eds := refEditors(ns, one, many)
+	return func(e *Entry) string {
+		if s := ns.format(e.Names("author")); s != "" {
+			return s
+		}
+		return eds(e)
+	}
+}
+
+// refFirst returns the value of the first non-empty field.
+func refFirst(fields ...string) func(*Entry) string {
+	return func(e *Entry) string {
+		for _, f := range fields {
+			if v := e.field(f); v != "" {
+				return unbrace(v)
+			}
+		}
+		return ""
+	}
+}
+
+// refYear returns the year of the entry, or undated if it has no date.
+func refYear(undated string) func(*Entry) string {
+	return func(e *Entry) string {
+		if y := e.Year(); y != "" {
+			return y
+		}
+		return undated
+	}
+}
+
+// refMonth returns the abbreviated month of the entry.
+func refMonth(e *Entry) string {
+	d, err := e.Date("date")
+	if err != nil || d.Month == 0 {
+		return ""
+	}
+	m := time.Month(d.Month).String()
+	if len(m) <= 4 {
+		return m
+	}
+	return m[:3] + "."
+}
+
+// refDate returns the value of a date field.
+func refDate(field string) func(*Entry) string {
+	return func(e *Entry) string {
+		d, err := e.Date(field)
+		if err != nil || d.Year == 0 {
+			return e.field(field)
+		}
+		if d.Month == 0 {
+			return strconv.Itoa(d.Year)
+		}
+		s := time.Month(d.Month).String() + " " + strconv.Itoa(d.Year)
+		if d.Day != 0 {
+			s = strconv.Itoa(d.Day) + " " + s
+		}
+		return s
+	}
+}
+
+// refPages returns the page ranges with an en dash, after one if it is a
+// single page, or many otherwise.
+func refPages(one, many string) func(*Entry) string {
+	return func(e *Entry) string {
+		v := e.field("pages")
+		ranges, err := ParseRanges(v)
+		if err != nil {
+			return v
+		}
+		var s []string
+		for _, r := range ranges {
+			s = append(s, strings.Replace(r.String(), "--", "–", 1))
+		}
+		if len(ranges) == 1 && ranges[0].End == "" {
+			return one + s[0]
+		}
+		return many + strings.Join(s, ", ")
+	}
+}
+
+// ordinal returns n as an English ordinal number, such as "2nd".
+func ordinal(n int) string {
+	s := strconv.Itoa(n)
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return s + "th"
+	case n%10 == 1:
+		return s + "st"
+	case n%10 == 2:
+		return s + "nd"
+	case n%10 == 3:
+		return s + "rd"
+	}
+	return s + "th"
+}
+
+// refEdition returns the edition followed by abbr, such as "2nd ed.", if it
+// is a number. The first edition is omitted.
+func refEdition(abbr string) func(*Entry) string {
+	return func(e *Entry) string {
+		v := e.field("edition")
+		n, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			return unbrace(v)
+		case n == 1:
+			return ""
+		}
+		return ordinal(n) + " " + abbr
+	}
+}
+
+// refLink returns the DOI of the entry as an URL, or the url of the entry.
+func refLink(e *Entry) string {
+	if doi := e.field("doi"); doi != "" {
+		return "https://doi.org/" + doi
+	}
+	return e.field("url")
+}
+
+// refLookup returns the description of the value of a field, such as "PhD
+// thesis" for "phdthesis". If the field is empty, it returns fallback.
+func refLookup(field string, desc map[string]string, fallback string) func(*Entry) string {
+	return func(e *Entry) string {
+		v := unbrace(e.field(field))
+		if v == "" {
+			return fallback
+		}
+		if d, ok := desc[strings.ToLower(v)]; ok {
+			return d
+		}
+		return v
+	}
+}
+
+var apaNames = nameStyle{
+	order:      nameInverted,
+	initials:   true,
+	initialSep: " ",
+	sep:        ", ",
+	and2:       ", & ",
+	andN:       ", & ",
+	etAlMin:    21,
+	etAlUse:    19,
+	etAl:       ", . . . ",
+	etAlLast:   true,
+}
+
+// citeNames returns the style of the names of in-text citations, which lists
+// up to etAlMin-1 last names.
+func citeNames(and2, andN string, etAlMin int) nameStyle {
+	return nameStyle{
+		short:   true,
+		sep:     ", ",
+		and2:    and2,
+		andN:    andN,
+		etAlMin: etAlMin,
+		etAlUse: 1,
+		etAl:    " et al.",
+	}
+}
+
+var apa = func() *textStyle {
+	editorNames := apaNames
+	editorNames.order = nameDirect
+
+	creator := block{value: refCreators(apaNames, " (Ed.)", " (Eds.)"), suffix: "."}
+	date := block{value: refYear("n.d."), prefix: "(", suffix: ")."}
+	title := block{value: refFirst("title"), suffix: "."}
+	doi := block{value: refLink, link: true}
+	publisher := block{value: refFirst("publisher", "institution", "organization"), suffix: "."}
+
+	inBook := group(" ",
+		creator, date, title,
+		block{blocks: []block{
+			{value: refEditors(editorNames, " (Ed.),", " (Eds.),")},
+			{value: refFirst("booktitle"), italic: true},
+			{value: refPages("p. ", "pp. "), prefix: "(", suffix: ")"},
+		}, delim: " ", prefix: "In ", suffix: "."},
+		publisher, doi,
+	)
+	misc := group(" ",
+		creator, date,
+		block{value: refFirst("title"), italic: true, suffix: "."},
+		block{value: refFirst("publisher", "howpublished", "organization", "institution"), suffix: "."},
+		doi,
+	)
+
+	return &textStyle{
+		sorted:     true,
+		quotes:     [2]string{"“", "”"},
+		cite:       group(", ", block{value: refCreators(citeNames(" & ", ", & ", 3), "", "")}, block{value: refYear("n.d.")}),
+		citePrefix: "(",
+		citeDelim:  "; ",
+		citeSuffix: ")",
+		layouts: map[string]block{
+			"article": group(" ",
+				creator, date, title,
+				block{blocks: []block{
+					{value: refFirst("journaltitle"), italic: true},
+					group("",
+						block{value: refFirst("volume"), italic: true},
+						block{value: refFirst("number"), prefix: "(", suffix: ")"},
+					),
+					{value: refPages("", "")},
+				}, delim: ", ", suffix: "."},
+				doi,
+			),
+			"book": group(" ",
+				creator, date,
+				block{blocks: []block{
+					{value: refFirst("title"), italic: true},
+					{value: refEdition("ed."), prefix: "(", suffix: ")"},
+				}, delim: " ", suffix: "."},
+				publisher, doi,
+			),
+			"inbook":        inBook,
+			"incollection":  inBook,
+			"inproceedings": inBook,
+			"thesis": group(" ",
+				creator, date,
+				block{blocks: []block{
+					{value: refFirst("title"), italic: true},
+					{blocks: []block{
+						{value: refLookup("type", map[string]string{
+							"phdthesis":     "Doctoral dissertation",
+							"mathesis":      "Master's thesis",
+							"mastersthesis": "Master's thesis",
+						}, "Doctoral dissertation")},
+						{value: refFirst("institution", "school")},
+					}, delim: ", ", prefix: "[", suffix: "]"},
+				}, delim: " ", suffix: "."},
+				doi,
+			),
+			"report": group(" ",
+				creator, date,
+				block{blocks: []block{
+					{value: refFirst("title"), italic: true},
+					{value: refFirst("number"), prefix: "(Report No. ", suffix: ")"},
+				}, delim: " ", suffix: "."},
+				publisher, doi,
+			),
+			"misc": misc,
+		},
+	}
+}()
+
+var ieeeNames = nameStyle{
+	order:      nameDirect,
+	initials:   true,
+	initialSep: " ",
+	sep:        ", ",
+	and2:       " and ",
+	andN:       ", and ",
+	etAlMin:    7,
+	etAlUse:    1,
+	etAl:       " et al.",
+}
+
+var ieee = func() *textStyle {
+	author := block{value: refCreators(ieeeNames, ", Ed.", ", Eds.")}
+	title := block{value: refFirst("title"), quoted: true}
+	location := block{value: refFirst("location", "address")}
+	publisher := group(": ", location, block{value: refFirst("publisher")})
+	inBook := group(" ",
+		block{blocks: []block{
+			author, title,
+			{value: refFirst("booktitle"), italic: true, prefix: "in "},
+			{value: refEditors(ieeeNames, ", Ed.", ", Eds.")},
+			{value: refEdition("ed.")},
+			publisher,
+			{value: refYear("")},
+			{value: refPages("p. ", "pp. ")},
+		}, delim: ", ", suffix: "."},
+		block{value: refFirst("doi"), prefix: "doi: ", suffix: "."},
+	)
+
+	return &textStyle{
+		label:         "[%d]",
+		citeDelim:     ", ",
+		quotes:        [2]string{"“", "”"},
+		punctInQuotes: true,
+		layouts: map[string]block{
+			"article": group(" ",
+				block{blocks: []block{
+					author, title,
+					{value: refFirst("journaltitle"), italic: true},
+					{value: refFirst("volume"), prefix: "vol. "},
+					{value: refFirst("number"), prefix: "no. "},
+					{value: refPages("p. ", "pp. ")},
+					group(" ", block{value: refMonth}, block{value: refYear("")}),
+				}, delim: ", ", suffix: "."},
+				block{value: refFirst("doi"), prefix: "doi: ", suffix: "."},
+			),
+			"book": group(" ",
+				block{blocks: []block{
+					author,
+					{value: refFirst("title"), italic: true},
+					{value: refEdition("ed.")},
+					publisher,
+					{value: refYear("")},
+				}, delim: ", ", suffix: "."},
+				block{value: refFirst("doi"), prefix: "doi: ", suffix: "."},
+			),
+			"inbook":       inBook,
+			"incollection": inBook,
+			"inproceedings": group(" ",
+				block{blocks: []block{
+					author, title,
+					{value: refFirst("booktitle"), italic: true, prefix: "in "},
+					location,
+					{value: refYear("")},
+					{value: refPages("p. ", "pp. ")},
+				}, delim: ", ", suffix: "."},
+				block{value: refFirst("doi"), prefix: "doi: ", suffix: "."},
+			),
+			"thesis": block{blocks: []block{
+				author, title,
+				{value: refLookup("type", map[string]string{
+					"phdthesis":     "Ph.D. dissertation",
+					"mathesis":      "M.S. thesis",
+					"mastersthesis": "M.S. thesis",
+				}, "Ph.D. dissertation")},
+				{value: refFirst("institution", "school")},
+				location,
+				{value: refYear("")},
+			}, delim: ", ", suffix: "."},
+			"report": block{blocks: []block{
+				author, title,
+				{value: refFirst("institution")},
+				location,
+				{value: refFirst("number"), prefix: "Tech. Rep. "},
+				{value: refYear("")},
+			}, delim: ", ", suffix: "."},
+			"misc": group(" ",
+				block{blocks: []block{
+					author, title,
+					{value: refFirst("publisher", "howpublished", "organization", "institution")},
+					{value: refYear("")},
+				}, delim: ", ", suffix: "."},
+				block{value: refFirst("url"), prefix: "[Online]. Available: "},
+			),
+		},
+	}
+}()
+
+var chicagoNames = nameStyle{
+	order:   nameFirstInverted,
+	sep:     ", ",
+	and2:    ", and ",
+	andN:    ", and ",
+	etAlMin: 11,
+	etAlUse: 7,
+	etAl:    " et al.",
+}
+
+var chicago = func() *textStyle {
+	editorNames := chicagoNames
+	editorNames.order = nameDirect
+	editorNames.and2 = " and "
+
+	author := block{value: refCreators(chicagoNames, ", ed.", ", eds."), suffix: "."}
+	title := block{value: refFirst("title"), quoted: true, suffix: "."}
+	publication := block{blocks: []block{
+		group(": ", block{value: refFirst("location", "address")}, block{value: refFirst("publisher", "institution")}),
+		{value: refYear("n.d.")},
+	}, delim: ", ", suffix: "."}
+	doi := block{value: refLink, link: true, suffix: "."}
+	inBook := group(" ",
+		author, title,
+		block{blocks: []block{
+			{value: refFirst("booktitle"), italic: true, prefix: "In "},
+			{value: refEditors(editorNames, "", ""), prefix: "edited by "},
+			{value: refPages("", "")},
+		}, delim: ", ", suffix: "."},
+		publication, doi,
+	)
+
+	return &textStyle{
+		sorted:        true,
+		quotes:        [2]string{"“", "”"},
+		punctInQuotes: true,
+		cite:          group(" ", block{value: refCreators(citeNames(" and ", ", and ", 4), "", "")}, block{value: refYear("n.d.")}),
+		citePrefix:    "(",
+		citeDelim:     "; ",
+		citeSuffix:    ")",
+		layouts: map[string]block{
+			"article": group(" ",
+				author, title,
+				block{blocks: []block{
+					group(" ",
+						block{value: refFirst("journaltitle"), italic: true},
+						group(", ", block{value: refFirst("volume")}, block{value: refFirst("number"), prefix: "no. "}),
+						block{value: refYear(""), prefix: "(", suffix: ")"},
+					),
+					{value: refPages("", ""), prefix: ": "},
+				}, suffix: "."},
+				doi,
+			),
+			"book": group(" ",
+				author,
+				block{value: refFirst("title"), italic: true, suffix: "."},
+				block{value: refEdition("ed."), suffix: "."},
+				publication, doi,
+			),
+			"inbook":        inBook,
+			"incollection":  inBook,
+			"inproceedings": inBook,
+			"thesis": group(" ",
+				author, title,
+				block{blocks: []block{
+					{value: refLookup("type", map[string]string{
+						"phdthesis":     "PhD diss.",
+						"mathesis":      "Master's thesis",
+						"mastersthesis": "Master's thesis",
+					}, "PhD diss.")},
+					{value: refFirst("institution", "school")},
+					{value: refYear("n.d.")},
+				}, delim: ", ", suffix: "."},
+				doi,
+			),
+			"report": group(" ",
+				author,
+				block{value: refFirst("title"), italic: true, suffix: "."},
+				block{value: refFirst("number"), prefix: "Report ", suffix: "."},
+				publication, doi,
+			),
+			"misc": group(" ",
+				author, title,
+				block{blocks: []block{
+					{value: refFirst("publisher", "howpublished", "organization", "institution")},
+					{value: refYear("n.d.")},
+				}, delim: ", ", suffix: "."},
+				doi,
+			),
+		},
+	}
+}()
+
+var harvardNames = nameStyle{
+	order:    nameInverted,
+	initials: true,
+	sep:      ", ",
+	and2:     " and ",
+	andN:     " and ",
+	etAlMin:  4,
+	etAlUse:  1,
+	etAl:     " et al.",
+}
+
+var harvard = func() *textStyle {
+	author := block{value: refCreators(harvardNames, " (ed.)", " (eds)")}
+	date := block{value: refYear("no date"), prefix: "(", suffix: ")"}
+	title := block{value: refFirst("title"), italic: true, suffix: "."}
+	publication := group(": ", block{value: refFirst("location", "address")}, block{value: refFirst("publisher", "institution")})
+	available := block{blocks: []block{
+		{value: refLink, prefix: "Available at: ", link: true},
+		{value: refDate("urldate"), prefix: "(Accessed: ", suffix: ")"},
+	}, delim: " ", suffix: "."}
+	inBook := group(" ",
+		author, date,
+		block{blocks: []block{
+			{value: refFirst("title"), quoted: true, suffix: ","},
+			{blocks: []block{
+				{value: refEditors(harvardNames, " (ed.)", " (eds)")},
+				{value: refFirst("booktitle"), italic: true},
+			}, delim: " ", prefix: "in "},
+		}, delim: " ", suffix: "."},
+		block{blocks: []block{publication, {value: refPages("p. ", "pp. ")}}, delim: ", ", suffix: "."},
+		available,
+	)
+
+	return &textStyle{
+		sorted:     true,
+		quotes:     [2]string{"‘", "’"},
+		cite:       group(", ", block{value: refCreators(citeNames(" and ", " and ", 4), "", "")}, block{value: refYear("no date")}),
+		citePrefix: "(",
+		citeDelim:  "; ",
+		citeSuffix: ")",
+		layouts: map[string]block{
+			"article": group(" ",
+				author, date,
+				block{blocks: []block{
+					{value: refFirst("title"), quoted: true},
+					{value: refFirst("journaltitle"), italic: true},
+					group("", block{value: refFirst("volume")}, block{value: refFirst("number"), prefix: "(", suffix: ")"}),
+					{value: refPages("p. ", "pp. ")},
+				}, delim: ", ", suffix: "."},
+				available,
+			),
+			"book": group(" ",
+				author, date, title,
+				block{value: refEdition("edn."), suffix: "."},
+				block{blocks: []block{publication}, suffix: "."},
+				available,
+			),
+			"inbook":        inBook,
+			"incollection":  inBook,
+			"inproceedings": inBook,
+			"thesis": group(" ",
+				author, date, title,
+				block{value: refLookup("type", map[string]string{
+					"phdthesis":     "PhD thesis",
+					"mathesis":      "Master's thesis",
+					"mastersthesis": "Master's thesis",
+				}, "PhD thesis"), suffix: "."},
+				block{value: refFirst("institution", "school"), suffix: "."},
+				available,
+			),
+			"report": group(" ",
+				author, date, title,
+				block{value: refFirst("number"), prefix: "Report ", suffix: "."},
+				block{blocks: []block{publication}, suffix: "."},
+				available,
+			),
+			"misc": group(" ",
+				author, date, title,
+				block{value: refFirst("publisher", "howpublished", "organization"), suffix: "."},
+				available,
+			),
+		},
+	}
+}()
@@ -0,0 +1,184 @@
+package scholar
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateExt is the extension of the files loaded by LoadTemplates.
+const TemplateExt = ".tmpl"
+
+// Template is an export format defined by a text template. The template is
+// executed with each *Entry to export. It can define a "header" and a "footer"
+// template, which are executed with the list of exported entries:
+//
+//	{{ define "header" }}Publications ({{ len . }}){{ "\n" }}{{ end -}}
+//	- {{ join ", " (fullnames . "author") }}. {{ field . "title" }}. {{ year . }}.
+//	{{ define "footer" }}Generated by Scholar{{ "\n" }}{{ end -}}
+//
+// Besides the functions of the text/template package, templates can use:
+//
+//	field ENTRY NAME       value of the field, required or optional
+//	year ENTRY             year of the entry
+//	names ENTRY NAME       list of Name of a name list field
+//	fullnames ENTRY NAME   "First von Last Jr" of each name
+//	lastnames ENTRY NAME   last name of each name
+//	join SEP LIST          elements of LIST separated by SEP
+//	date ENTRY NAME        Date of a date field, zero if invalid
+//	formatDate LAYOUT DATE date formatted with a time.Format layout
+//	lower, upper TEXT      text in lower or upper case
+//	latex TEXT             text with LaTeX special characters escaped
+//	xml TEXT               text with XML special characters escaped
+type Template struct {
+	name string
+	t    *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"field": func(e *Entry, name string) string {
+		return e.field(name)
+	},
+	"year": func(e *Entry) string {
+		return e.Year()
+	},
+	"names": func(e *Entry, name string) []Name {
+		return e.Names(name)
+	},
+	"fullnames": func(e *Entry, name string) []string {
+		var s []string
+		for _, n := range e.Names(name) {
+			s = append(s, n.Full())
+		}
+		return s
+	},
+	"lastnames": func(e *Entry, name string) []string {
+		var s []string
+		for _, n := range e.Names(name) {
+			s = append(s, n.LastName())
+		}
+		return s
+	},
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"date": func(e *Entry, name string) Date {
+		d, _ := e.Date(name)
+		return d
+	},
+	"formatDate": func(layout string, d Date) string {
+		if d.Year == 0 {
+			return ""
+		}
+		month, day := d.Month, d.Day
+		if month == 0 {
+			month = 1
+		}
+		if day == 0 {
+			day = 1
+		}
+		return time.Date(d.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC).Format(layout)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"latex": escapeLaTeX,
+	"xml":   html.EscapeString,
+}
+
+// ParseTemplate parses the text of an export format. It returns an
+// ErrInvalidTemplate error if the text cannot be parsed.
+func ParseTemplate(name, text string) (*Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, getError("ParseTemplate", ErrInvalidTemplate, err)
+	}
+	return &Template{name: name, t: t}, nil
+}
+
+// LoadTemplates parses all the *.tmpl files of a directory. The name of each
+// template is the name of its file without the extension.
+func LoadTemplates(dir string) ([]*Template, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+TemplateExt))
+	if err != nil {
+		return nil, getError("LoadTemplates", errNotDefined, err)
+	}
+	sort.Strings(files)
+
+	var ts []*Template
+	for _, file := range files {
+		d, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, getError("LoadTemplates", ErrIO, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), TemplateExt)
+		t, err := ParseTemplate(name, string(d))
+		if err != nil {
+			return nil, getError("LoadTemplates", errNotDefined, err).info(file)
+		}
+		ts = append(ts, t)
+	}
+
+	return ts, nil
+}
+
+// Name returns the name of the export format.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Begin implements the Exporter interface.
+func (t *Template) Begin(w io.Writer) error {
+	return nil
+}
+
+// Entry implements the Exporter interface. It returns an ErrInvalidTemplate
+// error if the template cannot be executed with the entry.
+func (t *Template) Entry(w io.Writer, e *Entry, i int) error {
+	return t.execute(w, t.t, e)
+}
+
+// End implements the Exporter interface.
+func (t *Template) End(w io.Writer) error {
+	return nil
+}
+
+// NeedsList implements the ListExporter interface. Only a template with a
+// header or a footer needs the whole list of entries.
+func (t *Template) NeedsList() bool {
+	return t.t.Lookup("header") != nil || t.t.Lookup("footer") != nil
+}
+
+// WriteList implements the ListExporter interface. It writes the header, each
+// entry, and the footer.
+func (t *Template) WriteList(w io.Writer, entries []*Entry) error {
+	if err := t.execute(w, t.t.Lookup("header"), entries); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if err := t.Entry(w, e, i); err != nil {
+			return err
+		}
+	}
+	return t.execute(w, t.t.Lookup("footer"), entries)
+}
+
+// execute writes the template executed with data. A missing template writes
+// nothing. The output is written only if the template succeeds, so an error
+// does not leave a partial entry.
+func (t *Template) execute(w io.Writer, tmpl *template.Template, data interface{}) error {
+	if tmpl == nil {
+		return nil
+	}
+	b := new(bytes.Buffer)
+	if err := tmpl.Execute(b, data); err != nil {
+		return getError("Export", ErrInvalidTemplate, err).info(t.name)
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
@@ -0,0 +1,86 @@
+package scholar
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mockTemplate = `{{ define "header" }}Publications ({{ len . }}){{ "\n" }}{{ end -}}
+- {{ join ", " (fullnames . "author") }}. {{ field . "title" | latex }}. {{ formatDate "Jan 2006" (date . "date") }}.
+{{ define "footer" }}End{{ "\n" }}{{ end -}}
+`
+
+func TestTemplate(t *testing.T) {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Required["title"] = "Cats & Dogs"
+	entry.Required["date"] = "2006-03"
+
+	tmpl, err := ParseTemplate("report", mockTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := new(strings.Builder)
+	if err := tmpl.Entry(b, entry, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "- First Last, Name Other. Cats \\& Dogs. Mar 2006.\n"; got != want {
+		t.Errorf("Entry() = %q, want %q", got, want)
+	}
+
+	b.Reset()
+	if err := tmpl.WriteList(b, []*Entry{entry}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "Publications (1)\n- First Last, Name Other. Cats \\& Dogs. Mar 2006.\nEnd\n"; got != want {
+		t.Errorf("WriteList() = %q, want %q", got, want)
+	}
+
+	t.Run("execution error", func(t *testing.T) {
+		bad, err := ParseTemplate("bad", `{{ .Key }} {{ index .Files 3 }}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := new(strings.Builder)
+		if err := bad.Entry(b, entry, 0); !IsError(ErrInvalidTemplate, err) {
+			t.Fatalf("Entry() error = %v, want ErrInvalidTemplate", err)
+		}
+		if b.Len() != 0 {
+			t.Errorf("Entry() wrote %q after an error", b.String())
+		}
+	})
+
+	if _, err := ParseTemplate("bad", "{{ field . "); !IsError(ErrInvalidTemplate, err) {
+		t.Errorf("ParseTemplate() error = %v, want ErrInvalidTemplate", err)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"report.tmpl": mockTemplate,
+		"keys.tmpl":   "{{ .Key }}\n",
+		"notes.txt":   "not a template",
+	}
+	for name, text := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 || ts[0].Name() != "keys" || ts[1].Name() != "report" {
+		t.Fatalf("LoadTemplates() = %v, want [keys report]", ts)
+	}
+	if ts[0].NeedsList() || !ts[1].NeedsList() {
+		t.Errorf("NeedsList() = %v %v, want false true", ts[0].NeedsList(), ts[1].NeedsList())
+	}
+}
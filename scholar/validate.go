@@ -0,0 +1,343 @@
+package scholar
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity indicates how serious a problem is.
+type Severity uint8
+
+const (
+	// SeverityError represents a problem that makes an entry invalid.
+	SeverityError Severity = iota
+	// SeverityWarning represents a problem that does not make an entry
+	// invalid, but should be reviewed.
+	SeverityWarning
+)
+
+// String implements the Stringer interface.
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// ProblemKind represents the kind of problem found in an entry.
+type ProblemKind uint8
+
+const (
+	// UnknownType represents an entry type that is not loaded.
+	UnknownType ProblemKind = iota
+	// MissingField represents an empty required field.
+	MissingField
+	// UnknownField represents a field not defined by the entry type.
+	UnknownField
+	// InvalidDate represents a date not in YYYY[-MM[-DD]] format.
+	InvalidDate
+	// InvalidDOI represents a malformed DOI.
+	InvalidDOI
+	// InvalidISBN represents an ISBN with a wrong length or checksum.
+	InvalidISBN
+	// InvalidISSN represents an ISSN with a wrong length or checksum.
+	InvalidISSN
+	// InvalidURL represents a URL that is not absolute.
+	InvalidURL
+	// InvalidRange represents a malformed range of pages.
+	InvalidRange
+	// InvalidInteger represents a value that is not an integer.
+	InvalidInteger
+	// InvalidPattern represents a value that does not match the pattern of
+	// the field.
+	InvalidPattern
+)
+
+// String implements the Stringer interface.
+func (k ProblemKind) String() string {
+	switch k {
+	case UnknownType:
+		return "unknown type"
+	case MissingField:
+		return "missing field"
+	case UnknownField:
+		return "unknown field"
+	case InvalidDate:
+		return "invalid date"
+	case InvalidDOI:
+		return "invalid DOI"
+	case InvalidISBN:
+		return "invalid ISBN"
+	case InvalidISSN:
+		return "invalid ISSN"
+	case InvalidURL:
+		return "invalid URL"
+	case InvalidRange:
+		return "invalid range"
+	case InvalidInteger:
+		return "invalid integer"
+	case InvalidPattern:
+		return "invalid pattern"
+	}
+
+	return "unknown problem"
+}
+
+// Problem describes an issue found in a field of an entry.
+type Problem struct {
+	Field    string
+	Kind     ProblemKind
+	Severity Severity
+	Message  string
+}
+
+// String implements the Stringer interface.
+func (p Problem) String() string {
+	if p.Field == "" {
+		return fmt.Sprintf("%s: %s: %s", p.Severity, p.Kind, p.Message)
+	}
+	return fmt.Sprintf("%s: %s[%s]: %s", p.Severity, p.Kind, p.Field, p.Message)
+}
+
+// HasErrors checks if any of the problems has SeverityError.
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the entry against its entry type and returns the list of
+// problems found, sorted by field. It reports empty required fields, fields
+// not defined by the entry type, values that do not match the datatype or
+// pattern of their field, and malformed DOIs, ISBNs, and ISSNs.
+func (e *Entry) Validate() []Problem {
+	var problems []Problem
+
+	add := func(field string, kind ProblemKind, severity Severity, format string, a ...interface{}) {
+		problems = append(problems, Problem{
+			Field:    field,
+			Kind:     kind,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, a...),
+		})
+	}
+
+	et, ok := EntryTypes[e.Type]
+	if !ok {
+		add("", UnknownType, SeverityError, "%q is not a valid entry type", e.Type)
+	} else {
+		for field := range et.Required {
+			if strings.TrimSpace(e.Required[field]) == "" && strings.TrimSpace(e.Optional[field]) == "" {
+				add(field, MissingField, SeverityError, "required by %q", e.Type)
+			}
+		}
+	}
+
+	fields := make(map[string]string)
+	for field, value := range e.Optional {
+		fields[field] = value
+	}
+	for field, value := range e.Required {
+		fields[field] = value
+	}
+
+	for field, value := range fields {
+		if ok {
+			_, req := et.Required[field]
+			_, opt := et.Optional[field]
+			if to := et.Canonical(field); to != field {
+				add(field, UnknownField, SeverityWarning, "alias of %q, use %q instead", to, to)
+			} else if !req && !opt {
+				add(field, UnknownField, SeverityWarning, "not defined by %q", e.Type)
+			}
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		kind, err := e.checkValue(field, value)
+		if err != nil {
+			add(field, kind, SeverityError, "%v", err)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Field != problems[j].Field {
+			return problems[i].Field < problems[j].Field
+		}
+		return problems[i].Kind < problems[j].Kind
+	})
+
+	return problems
+}
+
+// Check checks if the fields are formatted correctly. It returns an
+// ErrInvalidEntry error listing all the problems with SeverityError found by
+// Validate.
+func (e *Entry) Check() error {
+	var msg []string
+	for _, p := range e.Validate() {
+		if p.Severity == SeverityError {
+			msg = append(msg, p.String())
+		}
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+
+	return getError("Check", ErrInvalidEntry, nil).
+		info(fmt.Sprintf("%s: %s", e.Key, strings.Join(msg, "; ")))
+}
+
+// checkDate checks a date in YYYY[-MM[-DD]] format. Date ranges, such as
+// 1988/1992 or 1988-01/, are also accepted.
+func checkDate(date string) error {
+	parts := strings.Split(date, "/")
+	if len(parts) > 2 {
+		return fmt.Errorf("%q has more than one '/'", date)
+	}
+	for i, d := range parts {
+		if d == "" && len(parts) == 2 && (i == 1 || parts[1] != "") {
+			// open ended range
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01", d); err == nil {
+			continue
+		}
+		if _, err := time.Parse("2006", d); err == nil {
+			continue
+		}
+		return fmt.Errorf("%q is not in YYYY[-MM[-DD]] format", date)
+	}
+	return nil
+}
+
+var doiRx = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+
+func checkDOI(doi string) error {
+	if !doiRx.MatchString(doi) {
+		return fmt.Errorf("%q is not a DOI, use the 10.NNNN/suffix format without the resolver URL", doi)
+	}
+	return nil
+}
+
+// isbnDigits removes hyphens and spaces from an ISBN or ISSN.
+func isbnDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(s))
+}
+
+func checkISBN(isbn string) error {
+	d := isbnDigits(isbn)
+	switch len(d) {
+	case 10:
+		sum := 0
+		for i, r := range d {
+			v, ok := checkDigit(r, i == 9)
+			if !ok {
+				return fmt.Errorf("%q has an invalid character", isbn)
+			}
+			sum += (10 - i) * v
+		}
+		if sum%11 != 0 {
+			return fmt.Errorf("%q has an invalid checksum", isbn)
+		}
+	case 13:
+		sum := 0
+		for i, r := range d {
+			v, ok := checkDigit(r, false)
+			if !ok {
+				return fmt.Errorf("%q has an invalid character", isbn)
+			}
+			if i%2 == 1 {
+				v *= 3
+			}
+			sum += v
+		}
+		if sum%10 != 0 {
+			return fmt.Errorf("%q has an invalid checksum", isbn)
+		}
+	default:
+		return fmt.Errorf("%q does not have 10 or 13 digits", isbn)
+	}
+	return nil
+}
+
+func checkISSN(issn string) error {
+	d := isbnDigits(issn)
+	if len(d) != 8 {
+		return fmt.Errorf("%q does not have 8 digits", issn)
+	}
+	sum := 0
+	for i, r := range d {
+		v, ok := checkDigit(r, i == 7)
+		if !ok {
+			return fmt.Errorf("%q has an invalid character", issn)
+		}
+		sum += (8 - i) * v
+	}
+	if sum%11 != 0 {
+		return fmt.Errorf("%q has an invalid checksum", issn)
+	}
+	return nil
+}
+
+// checkDigit returns the value of a digit of an ISBN or ISSN. If x is true,
+// 'X' is accepted as 10.
+func checkDigit(r rune, x bool) (int, bool) {
+	if r >= '0' && r <= '9' {
+		return int(r - '0'), true
+	}
+	if x && r == 'X' {
+		return 10, true
+	}
+	return 0, false
+}
+
+func checkURL(u string) error {
+	p, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if p.Scheme == "" || p.Host == "" {
+		return fmt.Errorf("%q is not an absolute URL", u)
+	}
+	return nil
+}
+
+var rangeRx = regexp.MustCompile(`^([[:alnum:]]+)(?:\s*(?:-{1,3}|–|—)\s*([[:alnum:]]*))?$`)
+
+// checkRange checks a list of page ranges separated by commas, such as
+// "1-10", "1--10", "e123", or "1-3, 7".
+func checkRange(pages string) error {
+	for _, r := range strings.Split(pages, ",") {
+		r = strings.TrimSpace(r)
+		m := rangeRx.FindStringSubmatch(r)
+		if m == nil {
+			return fmt.Errorf("%q is not a range of pages", pages)
+		}
+		start, err1 := strconv.Atoi(m[1])
+		end, err2 := strconv.Atoi(m[2])
+		if err1 == nil && err2 == nil && end < start {
+			return fmt.Errorf("%q ends before it starts", r)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package scholar
+
+import (
+	"testing"
+)
+
+func mockValidEntry(t *testing.T) *Entry {
+	entry, err := mockEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Optional["doi"] = "10.1000/182"
+	return entry
+}
+
+func TestEntry_Validate(t *testing.T) {
+	entry := mockValidEntry(t)
+
+	if problems := entry.Validate(); len(problems) != 0 {
+		t.Fatalf("Validate() found problems in a valid entry: %v", problems)
+	}
+	if err := entry.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field    string
+		value    string
+		kind     ProblemKind
+		severity Severity
+	}{
+		{"title", "", MissingField, SeverityError},
+		{"date", "2006-13-01", InvalidDate, SeverityError},
+		{"doi", "https://doi.org/10.1000/182", InvalidDOI, SeverityError},
+		{"isbn", "978-0-306-40615-6", InvalidISBN, SeverityError},
+		{"isbn", "0-306-4061", InvalidISBN, SeverityError},
+		{"issn", "0378-5954", InvalidISSN, SeverityError},
+		{"url", "example.com/paper", InvalidURL, SeverityError},
+		{"pages", "10-1", InvalidRange, SeverityError},
+		{"pages", "1-2-3", InvalidRange, SeverityError},
+		{"unknown", "value", UnknownField, SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			entry := mockValidEntry(t)
+			if _, ok := entry.Required[tt.field]; ok {
+				entry.Required[tt.field] = tt.value
+			} else {
+				entry.Optional[tt.field] = tt.value
+			}
+
+			problems := entry.Validate()
+			found := false
+			for _, p := range problems {
+				if p.Field == tt.field && p.Kind == tt.kind && p.Severity == tt.severity {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Validate() = %v, want %s: %s[%s]", problems, tt.severity, tt.kind, tt.field)
+			}
+
+			err := entry.Check()
+			if tt.severity == SeverityError && !IsError(ErrInvalidEntry, err) {
+				t.Fatal("error other than ErrInvalidEntry:", err)
+			}
+			if tt.severity == SeverityWarning && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		entry := mockValidEntry(t)
+		entry.Type = "unknown"
+		problems := entry.Validate()
+		if len(problems) != 1 || problems[0].Kind != UnknownType {
+			t.Fatalf("Validate() = %v, want %s", problems, UnknownType)
+		}
+	})
+}
+
+func TestValidateFormats(t *testing.T) {
+	valid := []struct {
+		check func(string) error
+		value string
+	}{
+		{checkDate, "2006"},
+		{checkDate, "2006-01"},
+		{checkDate, "2006-01-02"},
+		{checkDate, "1988/1992"},
+		{checkDate, "1988-01/"},
+		{checkDOI, "10.1000/182"},
+		{checkISBN, "978-0-306-40615-7"},
+		{checkISBN, "0-306-40615-2"},
+		{checkISBN, "0-8044-2957-X"},
+		{checkISSN, "0378-5955"},
+		{checkISSN, "2434-561X"},
+		{checkURL, "https://example.com/paper.pdf"},
+		{checkRange, "12"},
+		{checkRange, "1--10"},
+		{checkRange, "e123"},
+		{checkRange, "1-3, 7"},
+		{checkRange, "xii-xv"},
+	}
+
+	for _, tt := range valid {
+		if err := tt.check(tt.value); err != nil {
+			t.Errorf("%q should be valid: %v", tt.value, err)
+		}
+	}
+}